@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// rateLimiter is a simple token-bucket limiter: up to burst requests fire
+// immediately, after which new tokens trickle in at rps per second. A nil
+// *rateLimiter is a valid no-op, used when --rps isn't set.
+type rateLimiter struct {
+	tokens chan struct{}
+	stop   chan struct{}
+}
+
+// newRateLimiter creates a rate limiter allowing burst requests up front and
+// rps per second thereafter. It returns nil (meaning "unlimited") if rps<=0.
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	rl := &rateLimiter{
+		tokens: make(chan struct{}, burst),
+		stop:   make(chan struct{}),
+	}
+	for i := 0; i < burst; i++ {
+		rl.tokens <- struct{}{}
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rps))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case rl.tokens <- struct{}{}:
+				default:
+					// bucket already full, drop this tick's token
+				}
+			case <-rl.stop:
+				return
+			}
+		}
+	}()
+
+	return rl
+}
+
+// wait blocks until a token is available or ctx is done. A nil rateLimiter
+// never blocks.
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	if rl == nil {
+		return nil
+	}
+	select {
+	case <-rl.tokens:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the token-refill goroutine. Safe to call on a nil rateLimiter.
+func (rl *rateLimiter) Close() {
+	if rl == nil {
+		return
+	}
+	close(rl.stop)
+}
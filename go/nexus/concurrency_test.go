@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSearchComponentsConcurrentPagination exercises searchComponents with
+// concurrency > 1 across many patterns, each with several continuation-token
+// pages, and a duplicate item on every page. Run with -race: the funnel
+// goroutine is the only thing allowed to touch seenImages/matchingImages, so
+// a regression there should show up as a data race even though the results
+// themselves would still look correct.
+func TestSearchComponentsConcurrentPagination(t *testing.T) {
+	const numPatterns = 8
+	const pagesPerPattern = 5
+
+	var mu sync.Mutex
+	requestsPerPattern := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		pattern := query.Get("name")
+		token := query.Get("continuationToken")
+
+		page := 0
+		if token != "" {
+			fmt.Sscanf(token, "page-%d", &page)
+		}
+
+		mu.Lock()
+		requestsPerPattern[pattern]++
+		mu.Unlock()
+
+		// Every page reports the same item twice, to exercise seenImages
+		// dedup under concurrent workers.
+		item := map[string]interface{}{
+			"name":    pattern,
+			"version": fmt.Sprintf("v%d", page),
+			"assets": []map[string]interface{}{
+				{"checksum": map[string]string{"sha256": fmt.Sprintf("sha-%s-%d", pattern, page)}},
+			},
+		}
+		resp := map[string]interface{}{"items": []map[string]interface{}{item, item}}
+		if page < pagesPerPattern-1 {
+			resp["continuationToken"] = fmt.Sprintf("page-%d", page+1)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := NewNexusSearch(server.URL, "test-repo", "", "", true, false, formatDocker, tagSchemeLexical, 0, "", nil, 4, nil)
+
+	var patterns []string
+	for i := 0; i < numPatterns; i++ {
+		patterns = append(patterns, fmt.Sprintf("pattern%d", i))
+	}
+
+	got, err := client.searchComponents(context.Background(), patterns)
+	if err != nil {
+		t.Fatalf("searchComponents() error = %v", err)
+	}
+
+	if len(got) != numPatterns*pagesPerPattern {
+		t.Fatalf("searchComponents() returned %d components, want %d (the per-page duplicate must be deduped)", len(got), numPatterns*pagesPerPattern)
+	}
+
+	seen := make(map[string]bool, len(got))
+	for _, c := range got {
+		key := c["name"] + ":" + c["version"]
+		if seen[key] {
+			t.Errorf("duplicate component %s in results", key)
+		}
+		seen[key] = true
+	}
+
+	for i := 0; i < numPatterns; i++ {
+		name := fmt.Sprintf("pattern%d", i)
+		mu.Lock()
+		n := requestsPerPattern[name]
+		mu.Unlock()
+		if n != pagesPerPattern {
+			t.Errorf("pattern %s: got %d page requests, want %d", name, n, pagesPerPattern)
+		}
+	}
+}
+
+func TestNewRateLimiterThrottles(t *testing.T) {
+	rl := newRateLimiter(50, 1) // ~20ms between tokens, burst of 1
+	defer rl.Close()
+
+	ctx := context.Background()
+	if err := rl.wait(ctx); err != nil {
+		t.Fatalf("first wait() error = %v, want nil (burst token is available immediately)", err)
+	}
+
+	start := time.Now()
+	if err := rl.wait(ctx); err != nil {
+		t.Fatalf("second wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("second wait() returned after %v, want it to block for close to 1/rps once the burst is spent", elapsed)
+	}
+}
+
+func TestNewRateLimiterZeroRPSIsUnlimited(t *testing.T) {
+	if rl := newRateLimiter(0, 1); rl != nil {
+		t.Errorf("newRateLimiter(0, ...) = %v, want nil", rl)
+	}
+
+	var rl *rateLimiter // nil *rateLimiter must behave as a no-op
+	if err := rl.wait(context.Background()); err != nil {
+		t.Errorf("nil rateLimiter.wait() error = %v, want nil", err)
+	}
+	rl.Close() // must not panic
+}
+
+func TestRateLimiterWaitRespectsContext(t *testing.T) {
+	rl := newRateLimiter(1, 1) // burst of 1 at 1rps: the second token is ~1s away
+	defer rl.Close()
+
+	if err := rl.wait(context.Background()); err != nil {
+		t.Fatalf("first wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := rl.wait(ctx); err == nil {
+		t.Error("wait() with an already-canceled context = nil, want error")
+	}
+}
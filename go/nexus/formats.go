@@ -0,0 +1,93 @@
+package main
+
+// Nexus search/component formats supported via --format. Nexus's
+// /service/rest/v1/search endpoint returns the same items/assets shape for
+// every format; only which fields identify a component, which checksum
+// algorithm its assets actually carry, and how its versions naturally order
+// differ between them.
+const (
+	formatDocker = "docker"
+	formatNpm    = "npm"
+	formatMaven2 = "maven2"
+	formatPypi   = "pypi"
+	formatRaw    = "raw"
+)
+
+// validFormats lists --format's accepted values, in flag-help order, for
+// error messages. searchFormats is the actual source of truth for which
+// formats are valid.
+var validFormats = []string{formatDocker, formatNpm, formatMaven2, formatPypi, formatRaw}
+
+// searchFormat tells searchComponents how to turn one raw search-result
+// component into the (name, version, checksum) tuple the rest of the
+// pipeline (filterTags, processImages, Prune) already works with, and which
+// --tag-scheme comparator ranks that format's versions by default.
+type searchFormat struct {
+	checksumAlgo  string // assets[0].checksum algorithm this format's repository actually publishes
+	defaultScheme string // --tag-scheme default when the flag is left unset
+
+	// key returns the grouping key for a component: the image/package name
+	// for docker/npm/pypi/raw, or "group:artifact" for maven2.
+	key func(component map[string]interface{}) string
+
+	// version returns the tag/version string for a component.
+	version func(component map[string]interface{}) string
+}
+
+var searchFormats = map[string]searchFormat{
+	formatDocker: {
+		checksumAlgo:  "sha256",
+		defaultScheme: tagSchemeSemver,
+		key:           func(c map[string]interface{}) string { return stringField(c, "name") },
+		version:       func(c map[string]interface{}) string { return stringField(c, "version") },
+	},
+	formatNpm: {
+		checksumAlgo:  "sha1",
+		defaultScheme: tagSchemeSemver,
+		key:           func(c map[string]interface{}) string { return stringField(c, "name") },
+		version:       func(c map[string]interface{}) string { return stringField(c, "version") },
+	},
+	formatMaven2: {
+		checksumAlgo:  "sha1",
+		defaultScheme: tagSchemeMaven,
+		key: func(c map[string]interface{}) string {
+			group, artifact := stringField(c, "group"), stringField(c, "name")
+			if group == "" {
+				return artifact
+			}
+			return group + ":" + artifact
+		},
+		version: func(c map[string]interface{}) string { return stringField(c, "version") },
+	},
+	formatPypi: {
+		checksumAlgo:  "sha256",
+		defaultScheme: tagSchemePep440,
+		key:           func(c map[string]interface{}) string { return stringField(c, "name") },
+		version:       func(c map[string]interface{}) string { return stringField(c, "version") },
+	},
+	formatRaw: {
+		checksumAlgo:  "sha256",
+		defaultScheme: tagSchemeLexical,
+		key:           func(c map[string]interface{}) string { return stringField(c, "name") },
+		// Raw components' "version" is frequently blank; fall back to the
+		// component's own asset path so --keep still has something to rank
+		// and group by.
+		version: func(c map[string]interface{}) string {
+			if v := stringField(c, "version"); v != "" {
+				return v
+			}
+			assets, _ := c["assets"].([]interface{})
+			if len(assets) > 0 {
+				if asset, ok := assets[0].(map[string]interface{}); ok {
+					return stringField(asset, "path")
+				}
+			}
+			return ""
+		},
+	},
+}
+
+func stringField(component map[string]interface{}, field string) string {
+	s, _ := component[field].(string)
+	return s
+}
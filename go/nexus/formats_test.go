@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestSearchFormatKeyAndVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		component   map[string]interface{}
+		wantKey     string
+		wantVersion string
+	}{
+		{
+			name:        "Docker: name and version straight from the component",
+			format:      formatDocker,
+			component:   map[string]interface{}{"name": "library/nginx", "version": "1.25"},
+			wantKey:     "library/nginx",
+			wantVersion: "1.25",
+		},
+		{
+			name:        "Maven2: key is group:artifact",
+			format:      formatMaven2,
+			component:   map[string]interface{}{"group": "com.example", "name": "widget", "version": "1.0.1"},
+			wantKey:     "com.example:widget",
+			wantVersion: "1.0.1",
+		},
+		{
+			name:        "Maven2: missing group falls back to bare artifact",
+			format:      formatMaven2,
+			component:   map[string]interface{}{"name": "widget", "version": "1.0.1"},
+			wantKey:     "widget",
+			wantVersion: "1.0.1",
+		},
+		{
+			name:   "Raw: blank version falls back to the asset path",
+			format: formatRaw,
+			component: map[string]interface{}{
+				"name":    "releases/widget",
+				"version": "",
+				"assets": []interface{}{
+					map[string]interface{}{"path": "/releases/widget/widget-1.0.tar.gz"},
+				},
+			},
+			wantKey:     "releases/widget",
+			wantVersion: "/releases/widget/widget-1.0.tar.gz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sf := searchFormats[tt.format]
+			if got := sf.key(tt.component); got != tt.wantKey {
+				t.Errorf("key() = %q, want %q", got, tt.wantKey)
+			}
+			if got := sf.version(tt.component); got != tt.wantVersion {
+				t.Errorf("version() = %q, want %q", got, tt.wantVersion)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
@@ -8,26 +9,60 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
-	"sort"
-	"strconv"
+	"os/signal"
+	"runtime"
 	"strings"
+	"sync"
 )
 
-// NexusDockerSearch represents a client for searching Docker images in a Nexus repository
-type NexusDockerSearch struct {
-	nexusURL   string
-	repository string
-	username   string
-	password   string
-	verifySSL  bool
-	verbose    bool
-	authHeader string
-	httpClient *http.Client
+// Searcher finds Nexus/registry components matching a set of name patterns
+// and returns, for each matching component, the tags (versions) that should
+// be kept after filtering. NexusSearch and RegistryV2Search both implement
+// it so the rest of the pipeline (filterTags, processImages) doesn't care
+// which backend found the components. ctx cancels in-flight HTTP calls,
+// e.g. on SIGINT or --timeout.
+type Searcher interface {
+	SearchImages(ctx context.Context, patterns []string) (map[string][]string, error)
 }
 
-// NewNexusDockerSearch creates a new NexusDockerSearch client
-func NewNexusDockerSearch(nexusURL, repository, username, password string, verifySSL, verbose bool) *NexusDockerSearch {
+// NexusSearch is a client for Nexus's /service/rest/v1/search API, able to
+// search and retain/prune components of any format searchFormats knows
+// about (see --format): docker, npm, maven2, pypi or raw.
+type NexusSearch struct {
+	baseURL     string
+	nexusURL    string
+	repository  string
+	username    string
+	password    string
+	verifySSL   bool
+	verbose     bool
+	format      string
+	tagScheme   string
+	keep        int
+	platform    string
+	authHeader  string
+	httpClient  *http.Client
+	verifier    Verifier
+	concurrency int
+	limiter     *rateLimiter
+}
+
+// NewNexusSearch creates a new NexusSearch client. format selects the
+// searchFormats entry that extracts a component's grouping key and version
+// (see --format); tagScheme selects the --tag-scheme comparator (see
+// rankTags) and keep is the number of top-ranked tags to retain per
+// component. platform picks a manifest-list entry's digest for ranking,
+// exactly as it does for NewRegistryV2Search, and only applies to
+// format == formatDocker; it relies on the same host also serving the
+// repository's standard /v2/ API, which is how Nexus exposes Docker
+// repositories. verifier, if non-nil, is consulted for every kept tag's
+// cosign signature (see --require-signature in main); nil skips signature
+// verification entirely. concurrency bounds how many patterns
+// searchComponents works on at once (see --concurrency), and limiter, if
+// non-nil, throttles the rate of outgoing requests (see --rps/--burst).
+func NewNexusSearch(nexusURL, repository, username, password string, verifySSL, verbose bool, format, tagScheme string, keep int, platform string, verifier Verifier, concurrency int, limiter *rateLimiter) *NexusSearch {
 	// Create HTTP client with SSL configuration
 	transport := &http.Transport{
 		TLSClientConfig: &tls.Config{
@@ -43,37 +78,55 @@ func NewNexusDockerSearch(nexusURL, repository, username, password string, verif
 		authHeader = "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
 	}
 
-	return &NexusDockerSearch{
-		nexusURL:   strings.TrimRight(nexusURL, "/") + "/service/rest/v1/search",
-		repository: repository,
-		username:   username,
-		password:   password,
-		verifySSL:  verifySSL,
-		verbose:    verbose,
-		authHeader: authHeader,
-		httpClient: client,
+	base := strings.TrimRight(nexusURL, "/")
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &NexusSearch{
+		baseURL:     base,
+		nexusURL:    base + "/service/rest/v1/search",
+		repository:  repository,
+		username:    username,
+		password:    password,
+		verifySSL:   verifySSL,
+		verbose:     verbose,
+		format:      format,
+		tagScheme:   tagScheme,
+		keep:        keep,
+		platform:    platform,
+		authHeader:  authHeader,
+		httpClient:  client,
+		verifier:    verifier,
+		concurrency: concurrency,
+		limiter:     limiter,
 	}
 }
 
-// makeRequest performs an HTTP GET request with authentication
-func (n *NexusDockerSearch) makeRequest(url string, params map[string]string) (map[string]interface{}, error) {
-	// Add query parameters to URL
+// makeRequest performs an HTTP GET request with authentication. ctx cancels
+// the request (e.g. on SIGINT or --timeout) even mid-flight.
+func (n *NexusSearch) makeRequest(ctx context.Context, reqURL string, params map[string]string) (map[string]interface{}, error) {
 	if len(params) > 0 {
-		query := make([]string, 0, len(params))
+		values := url.Values{}
 		for k, v := range params {
-			query = append(query, fmt.Sprintf("%s=%s", k, v))
+			values.Set(k, v)
 		}
-		url = url + "?" + strings.Join(query, "&")
+		reqURL = reqURL + "?" + values.Encode()
 	}
 
 	if n.verbose {
-		fmt.Printf("Making request to: %s\n", url)
+		fmt.Printf("Making request to: %s\n", reqURL)
 		if len(params) > 0 {
 			fmt.Printf("Query parameters: %v\n", params)
 		}
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	if err := n.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -105,161 +158,390 @@ func (n *NexusDockerSearch) makeRequest(url string, params map[string]string) (m
 	return result, nil
 }
 
-// SearchImages searches for Docker images matching the given patterns
-func (n *NexusDockerSearch) SearchImages(patterns []string) (map[string][]string, error) {
-	if n.verbose {
-		fmt.Printf("Searching for patterns: %v\n", patterns)
+// SearchImages searches for Docker images matching the given patterns. Unlike
+// SearchImagesDetailed it never fetches manifests: Nexus's search API
+// already returns a usable digest, so the plain tag-name pipeline stays as
+// cheap as it was before multi-arch awareness existed.
+func (n *NexusSearch) SearchImages(ctx context.Context, patterns []string) (map[string][]string, error) {
+	matchingImages, err := n.searchComponents(ctx, patterns)
+	if err != nil {
+		return nil, err
 	}
 
-	// Build base search parameters
-	baseParams := map[string]string{
-		"repository": n.repository,
-		"format":     "docker",
+	// Process and filter the images
+	kept, err := processImages(matchingImages, n.verbose, n.tagScheme, n.keep)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get all components for each pattern
-	matchingImages := make([]map[string]string, 0)
-	seenImages := make(map[string]bool) // Track unique images by name:version
+	if n.verifier != nil {
+		kept = filterVerified(ctx, n, n.verifier, kept, buildDigestIndex(matchingImages))
+	}
 
-	for _, pattern := range patterns {
-		if n.verbose {
-			fmt.Printf("Searching with pattern: %s\n", pattern)
-		}
+	return kept, nil
+}
 
-		// Add pattern to parameters
-		params := make(map[string]string)
-		for k, v := range baseParams {
-			params[k] = v
-		}
-		params["name"] = pattern
+// SearchImagesDetailed is like SearchImages but, for format == formatDocker,
+// reports multi-arch manifest list / OCI image index structure per kept tag
+// instead of just its name. Other formats have no /v2/ API to fetch
+// manifests from, so their kept tags are reported with no platform info.
+func (n *NexusSearch) SearchImagesDetailed(ctx context.Context, patterns []string) (map[string][]TagInfo, error) {
+	components, err := n.searchComponents(ctx, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make(map[string]manifestInfo, len(components))
+	if n.format == formatDocker {
+		// The Nexus Docker repository backing this search also serves the
+		// standard /v2/ API; fetch each component's manifest to pick up
+		// multi-arch platform info and, when --platform is set, the
+		// platform-specific digest for ranking.
+		for _, component := range components {
+			name, tag := component["name"], component["version"]
 
-		continuationToken := ""
-		for {
-			if continuationToken != "" {
-				params["continuationToken"] = continuationToken
+			info, err := n.fetchManifestInfo(ctx, name, tag)
+			if err != nil {
 				if n.verbose {
-					fmt.Printf("Fetching next page with token: %s\n", continuationToken)
+					fmt.Printf("Could not fetch manifest for %s:%s: %v\n", name, tag, err)
 				}
+				continue
 			}
+			manifests[name+":"+tag] = info
 
-			data, err := n.makeRequest(n.nexusURL, params)
-			if err != nil {
-				return nil, fmt.Errorf("search failed: %v", err)
+			if digest, ok := info.platformDigest(n.platform); ok {
+				component["sha256"] = digest
 			}
+		}
+	}
 
-			items, ok := data["items"].([]interface{})
-			if !ok {
-				return nil, fmt.Errorf("invalid response format: items not found")
-			}
+	kept, err := processImages(components, n.verbose, n.tagScheme, n.keep)
+	if err != nil {
+		return nil, err
+	}
 
-			if n.verbose {
-				fmt.Printf("Found %d components in current page\n", len(items))
-			}
+	if n.verifier != nil {
+		kept = filterVerified(ctx, n, n.verifier, kept, buildDigestIndex(components))
+	}
+
+	return buildDetailedResults(kept, manifests), nil
+}
+
+// fetchManifestInfo fetches the manifest for name:ref from the repository's
+// /v2/ API, which Nexus serves alongside its proprietary search endpoint.
+func (n *NexusSearch) fetchManifestInfo(ctx context.Context, name, ref string) (manifestInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", n.baseURL+"/v2/"+name+"/manifests/"+ref, nil)
+	if err != nil {
+		return manifestInfo{}, fmt.Errorf("failed to create manifest request: %v", err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestAcceptTypes, ", "))
+	if n.authHeader != "" {
+		req.Header.Add("Authorization", n.authHeader)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return manifestInfo{}, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return manifestInfo{}, fmt.Errorf("manifest request for %s:%s returned HTTP %d", name, ref, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return manifestInfo{}, fmt.Errorf("failed to read manifest body: %v", err)
+	}
+
+	digest := strings.TrimPrefix(resp.Header.Get("Docker-Content-Digest"), "sha256:")
+	return parseManifestBody(body, digest)
+}
+
+// fetchRawManifest fetches the manifest for name:ref and returns its raw
+// body, unparsed. Signature lookup (verifyTagSignature) uses this to read a
+// cosign signature artifact's layers/annotations, which manifestInfo doesn't
+// carry.
+func (n *NexusSearch) fetchRawManifest(ctx context.Context, name, ref string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", n.baseURL+"/v2/"+name+"/manifests/"+ref, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest request: %v", err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestAcceptTypes, ", "))
+	if n.authHeader != "" {
+		req.Header.Add("Authorization", n.authHeader)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("manifest request for %s:%s returned HTTP %d", name, ref, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// fetchBlob fetches a content-addressed blob (e.g. a signature's signed
+// payload) by digest, which must include its algorithm prefix (e.g. "sha256:...").
+func (n *NexusSearch) fetchBlob(ctx context.Context, name, digest string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", n.baseURL+"/v2/"+name+"/blobs/"+digest, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob request: %v", err)
+	}
+	if n.authHeader != "" {
+		req.Header.Add("Authorization", n.authHeader)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("blob request for %s@%s returned HTTP %d", name, digest, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// searchComponents runs the Nexus component search, in n.format, for
+// patterns and returns one map per matched component, carrying everything
+// downstream consumers need: processImages reads name/version/sha256, Prune
+// additionally reads id (for the components delete API) and lastModified
+// (for --min-age). searchFormats[n.format] supplies the grouping key and
+// version for that format, so this logic itself stays format-agnostic.
+//
+// Up to n.concurrency patterns are searched at once, each by its own worker.
+// A pattern's continuation-token pages are necessarily fetched one at a
+// time (the token for page N+1 only exists once page N's response is in),
+// but a worker doesn't wait for a page to be deduplicated before fetching
+// the next one: it sends the page's components down itemCh and immediately
+// requests the next page, while a single funnel goroutine drains itemCh and
+// owns seenImages/matchingImages, so neither needs a mutex.
+func (n *NexusSearch) searchComponents(ctx context.Context, patterns []string) ([]map[string]string, error) {
+	if n.verbose {
+		fmt.Printf("Searching for patterns: %v\n", patterns)
+	}
+
+	sf := searchFormats[n.format]
+
+	baseParams := map[string]string{
+		"repository": n.repository,
+		"format":     n.format,
+	}
+
+	patternCh := make(chan string)
+	itemCh := make(chan map[string]interface{})
+	errCh := make(chan error, 1)
+	reportErr := func(err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
+	}
 
-			// Process components
-			for _, item := range items {
-				component, ok := item.(map[string]interface{})
-				if !ok {
-					continue
+	var workers sync.WaitGroup
+	for i := 0; i < n.concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for pattern := range patternCh {
+				if n.verbose {
+					fmt.Printf("Searching with pattern: %s\n", pattern)
 				}
 
-				name, _ := component["name"].(string)
-				version, _ := component["version"].(string)
-				imageKey := name + ":" + version
+				params := make(map[string]string, len(baseParams)+2)
+				for k, v := range baseParams {
+					params[k] = v
+				}
+				params["name"] = pattern
+
+				continuationToken := ""
+				for {
+					if continuationToken != "" {
+						params["continuationToken"] = continuationToken
+						if n.verbose {
+							fmt.Printf("Fetching next page with token: %s\n", continuationToken)
+						}
+					}
+
+					data, err := n.makeRequest(ctx, n.nexusURL, params)
+					if err != nil {
+						reportErr(fmt.Errorf("search failed: %v", err))
+						return
+					}
+
+					items, ok := data["items"].([]interface{})
+					if !ok {
+						reportErr(fmt.Errorf("invalid response format: items not found"))
+						return
+					}
 
-				// Skip if we've already seen this image:version
-				if seenImages[imageKey] {
 					if n.verbose {
-						fmt.Printf("Skipping duplicate image: %s\n", imageKey)
+						fmt.Printf("Found %d components in current page\n", len(items))
 					}
-					continue
-				}
 
-				seenImages[imageKey] = true
-				if n.verbose {
-					fmt.Printf("Found image: %s\n", name)
-				}
+					for _, item := range items {
+						component, ok := item.(map[string]interface{})
+						if !ok {
+							continue
+						}
+						select {
+						case itemCh <- component:
+						case <-ctx.Done():
+							return
+						}
+					}
 
-				// Get SHA256 from assets
-				assets, _ := component["assets"].([]interface{})
-				var sha256 string
-				if len(assets) > 0 {
-					asset, _ := assets[0].(map[string]interface{})
-					checksum, _ := asset["checksum"].(map[string]interface{})
-					sha256, _ = checksum["sha256"].(string)
+					token, ok := data["continuationToken"].(string)
+					if !ok || token == "" {
+						if n.verbose {
+							fmt.Println("No more pages to fetch")
+						}
+						break
+					}
+					continuationToken = token
 				}
+			}
+		}()
+	}
 
-				matchingImages = append(matchingImages, map[string]string{
-					"name":    name,
-					"version": version,
-					"sha256":  sha256,
-				})
+	go func() {
+		defer close(patternCh)
+		for _, pattern := range patterns {
+			select {
+			case patternCh <- pattern:
+			case <-ctx.Done():
+				return
 			}
+		}
+	}()
 
-			// Check if there are more results
-			if token, ok := data["continuationToken"].(string); ok && token != "" {
-				continuationToken = token
-			} else {
-				if n.verbose {
-					fmt.Println("No more pages to fetch")
-				}
-				break
+	go func() {
+		workers.Wait()
+		close(itemCh)
+	}()
+
+	matchingImages := make([]map[string]string, 0)
+	seenImages := make(map[string]bool) // owned solely by this goroutine
+	for component := range itemCh {
+		name := sf.key(component)
+		version := sf.version(component)
+		id, _ := component["id"].(string)
+		imageKey := name + ":" + version
+
+		if seenImages[imageKey] {
+			if n.verbose {
+				fmt.Printf("Skipping duplicate image: %s\n", imageKey)
 			}
+			continue
+		}
+		seenImages[imageKey] = true
+		if n.verbose {
+			fmt.Printf("Found image: %s\n", name)
+		}
+
+		// "sha256" stays the map key across every format for downstream
+		// consumers (processImages, Prune, signature verification), even
+		// though the digest it holds is sf.checksumAlgo, not always sha256.
+		assets, _ := component["assets"].([]interface{})
+		var sha256, lastModified string
+		if len(assets) > 0 {
+			asset, _ := assets[0].(map[string]interface{})
+			checksum, _ := asset["checksum"].(map[string]interface{})
+			sha256, _ = checksum[sf.checksumAlgo].(string)
+			lastModified, _ = asset["lastModified"].(string)
 		}
+
+		matchingImages = append(matchingImages, map[string]string{
+			"name":         name,
+			"version":      version,
+			"sha256":       sha256,
+			"id":           id,
+			"lastModified": lastModified,
+		})
+	}
+
+	select {
+	case err := <-errCh:
+		return nil, err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	if n.verbose {
 		fmt.Printf("Total matching images found: %d\n", len(matchingImages))
 	}
 
-	// Process and filter the images
-	return n.processImages(matchingImages)
+	return matchingImages, nil
 }
 
-// filterTags filters and sorts tags for an image
-func (n *NexusDockerSearch) filterTags(tags []string, latestDigest, versionDigest string) []string {
+// buildDigestIndex maps "name:tag" to its digest for every scanned image, so
+// signature verification can look up which digest a kept tag's signature
+// must name without re-threading digests through processImages.
+func buildDigestIndex(images []map[string]string) map[string]string {
+	index := make(map[string]string, len(images))
+	for _, image := range images {
+		index[image["name"]+":"+image["version"]] = image["sha256"]
+	}
+	return index
+}
+
+// filterTags ranks an image's tags under scheme and keeps the top N
+// (excluding "latest", which is re-attached at the front if its digest
+// matches the top-ranked version's digest). Tags that don't parse under
+// scheme are reported via unparsedFn, if set, rather than silently dropped.
+func filterTags(tags []string, digests map[string]string, scheme string, keep int, unparsedFn func(tag string)) []string {
 	if len(tags) == 0 {
 		return nil
 	}
 
-	// Separate latest tag from other tags
 	var versionTags []string
+	hasLatest := false
 	for _, tag := range tags {
-		if tag != "latest" {
-			versionTags = append(versionTags, tag)
+		if tag == "latest" {
+			hasLatest = true
+			continue
 		}
+		versionTags = append(versionTags, tag)
 	}
 
-	// Sort version tags numerically
-	sort.Slice(versionTags, func(i, j int) bool {
-		numI, errI := strconv.Atoi(versionTags[i])
-		numJ, errJ := strconv.Atoi(versionTags[j])
-		if errI != nil || errJ != nil {
-			return false
+	ranked, unparsed := rankTags(versionTags, scheme)
+	if unparsedFn != nil {
+		for _, tag := range unparsed {
+			unparsedFn(tag)
 		}
-		return numI > numJ
-	})
+	}
 
-	// Take only the last 2 valid tags
-	if len(versionTags) > 2 {
-		versionTags = versionTags[:2]
+	if keep > 0 && len(ranked) > keep {
+		ranked = ranked[:keep]
 	}
 
-	// If latest tag exists and matches the highest version, include it
-	for _, tag := range tags {
-		if tag == "latest" && latestDigest != "" && versionDigest != "" {
-			if latestDigest == versionDigest {
-				return append([]string{"latest"}, versionTags...)
-			}
+	// If latest exists and its digest matches the top-ranked version's
+	// digest (computed before truncation to keep), include it.
+	if hasLatest && len(ranked) > 0 {
+		latestDigest := digests["latest"]
+		topDigest := digests[ranked[0]]
+		if latestDigest != "" && topDigest != "" && latestDigest == topDigest {
+			return append([]string{"latest"}, ranked...)
 		}
 	}
 
-	return versionTags
+	return ranked
 }
 
-// processImages processes a list of images and their tags
-func (n *NexusDockerSearch) processImages(images []map[string]string) (map[string][]string, error) {
-	if n.verbose {
+// processImages processes a list of images and their tags. It is shared by
+// every Searcher backend so tag filtering behaves identically regardless of
+// where the (name, version, sha256) tuples came from.
+func processImages(images []map[string]string, verbose bool, scheme string, keep int) (map[string][]string, error) {
+	if verbose {
 		fmt.Println("Processing and filtering image tags")
 	}
 
@@ -273,34 +555,26 @@ func (n *NexusDockerSearch) processImages(images []map[string]string) (map[strin
 	// Process each group
 	results := make(map[string][]string)
 	for name, versions := range imageGroups {
-		if n.verbose {
+		if verbose {
 			fmt.Printf("Processing tags for image: %s\n", name)
 		}
 
-		// Get all versions and their digests
 		var tags []string
-		var latestDigest, versionDigest string
-		var highestVersion int
-
+		digests := make(map[string]string)
 		for _, versionInfo := range versions {
 			version := versionInfo["version"]
-			sha256 := versionInfo["sha256"]
 			tags = append(tags, version)
+			digests[version] = versionInfo["sha256"]
+		}
 
-			if version == "latest" {
-				latestDigest = sha256
-			} else {
-				if versionNum, err := strconv.Atoi(version); err == nil {
-					if versionNum > highestVersion {
-						highestVersion = versionNum
-						versionDigest = sha256
-					}
-				}
+		var unparsedFn func(tag string)
+		if verbose {
+			unparsedFn = func(tag string) {
+				fmt.Printf("Tag %q does not parse under %s scheme, dropping\n", tag, scheme)
 			}
 		}
 
-		// Filter and sort tags
-		filteredTags := n.filterTags(tags, latestDigest, versionDigest)
+		filteredTags := filterTags(tags, digests, scheme, keep, unparsedFn)
 		if len(filteredTags) > 0 {
 			results[name] = filteredTags
 		}
@@ -311,13 +585,32 @@ func (n *NexusDockerSearch) processImages(images []map[string]string) (map[strin
 
 func main() {
 	// Parse command line arguments
-	url := flag.String("url", "", "Nexus server URL")
-	repository := flag.String("repository", "", "Docker repository name")
-	username := flag.String("username", "", "Nexus username")
-	password := flag.String("password", "", "Nexus password")
+	url := flag.String("url", "", "Nexus server URL or, for --backend=registry-v2, the registry base URL")
+	repository := flag.String("repository", "", "Docker repository name (nexus backend) or repository path prefix to search (registry-v2 backend)")
+	username := flag.String("username", "", "Username")
+	password := flag.String("password", "", "Password")
 	noVerifySSL := flag.Bool("no-verify-ssl", false, "Disable SSL certificate verification")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging")
 	output := flag.String("output", "", "Output file path")
+	backend := flag.String("backend", "nexus", "Search backend to use: nexus or registry-v2")
+	format := flag.String("format", formatDocker, "With --backend=nexus, the component format to search: docker, npm, maven2, pypi or raw")
+	tagScheme := flag.String("tag-scheme", "", "Tag ordering scheme: semver, numeric, calver, lexical, maven or pep440 (defaults to the --format's natural scheme)")
+	keep := flag.Int("keep", 2, "Number of top-ranked tags to retain per image")
+	prune := flag.Bool("prune", false, "Delete tags that aren't kept after filtering (nexus backend only)")
+	dryRun := flag.Bool("dry-run", true, "With --prune, report what would be deleted without deleting it")
+	minAge := flag.Duration("min-age", 0, "With --prune, never delete a component younger than this")
+	keepLatest := flag.Bool("keep-latest", false, "With --prune, never delete the \"latest\" tag")
+	exclude := flag.String("exclude", "", "With --prune, never delete tags matching this glob pattern")
+	platform := flag.String("platform", "", "Restrict ranking/output to one platform of a multi-arch tag, e.g. linux/amd64")
+	legacyOutput := flag.Bool("legacy-output", false, "Emit the old map[string][]string shape instead of structured per-platform tag info")
+	requireSignature := flag.Bool("require-signature", false, "Drop tags whose cosign signature can't be found/verified")
+	cosignKey := flag.String("cosign-key", "", "With --require-signature, verify against this PEM-encoded public key instead of the keyless flow")
+	certIdentity := flag.String("cert-identity", "", "With --require-signature, the keyless signing certificate's expected SAN/email identity")
+	certOIDCIssuer := flag.String("cert-oidc-issuer", "", "With --require-signature, the keyless signing certificate's expected OIDC issuer")
+	concurrency := flag.Int("concurrency", runtime.GOMAXPROCS(0), "Number of patterns to search concurrently (nexus backend only)")
+	rps := flag.Float64("rps", 0, "Rate-limit outgoing requests to this many per second (0 disables the limit)")
+	burst := flag.Int("burst", 1, "With --rps, how many requests may fire immediately before the limit kicks in")
+	timeout := flag.Duration("timeout", 0, "Cancel the whole run after this long (0 means no timeout); SIGINT cancels it immediately either way")
 	flag.Parse()
 
 	patterns := flag.Args()
@@ -326,23 +619,127 @@ func main() {
 		os.Exit(1)
 	}
 
-	if *url == "" || *repository == "" {
-		fmt.Println("Error: --url and --repository are required")
+	if *url == "" {
+		fmt.Println("Error: --url is required")
 		os.Exit(1)
 	}
 
-	// Initialize client
-	client := NewNexusDockerSearch(*url, *repository, *username, *password, !*noVerifySSL, *verbose)
+	if *backend == "nexus" {
+		if _, ok := searchFormats[*format]; !ok {
+			fmt.Printf("Error: unknown format %q (want %s)\n", *format, strings.Join(validFormats, ", "))
+			os.Exit(1)
+		}
+	}
 
-	// Search for images
-	results, err := client.SearchImages(patterns)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
+	scheme := *tagScheme
+	if scheme == "" {
+		if *backend == "nexus" {
+			scheme = searchFormats[*format].defaultScheme
+		} else {
+			scheme = tagSchemeSemver
+		}
+	}
+	switch scheme {
+	case tagSchemeSemver, tagSchemeNumeric, tagSchemeCalver, tagSchemeLexical, tagSchemeMaven, tagSchemePep440:
+	default:
+		fmt.Printf("Error: unknown tag scheme %q (want semver, numeric, calver, lexical, maven or pep440)\n", scheme)
+		os.Exit(1)
+	}
+
+	if *prune && *backend != "nexus" {
+		fmt.Println("Error: --prune is only supported with --backend=nexus")
 		os.Exit(1)
 	}
 
+	if *requireSignature && *backend == "nexus" && *format != formatDocker {
+		fmt.Println("Error: --require-signature needs a Docker /v2/ API to fetch signature artifacts from, so it only supports --format=docker")
+		os.Exit(1)
+	}
+
+	var verifier Verifier
+	if *requireSignature {
+		switch {
+		case *cosignKey != "":
+			v, err := NewStaticKeyVerifier(*cosignKey)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			verifier = v
+		case *certIdentity != "" || *certOIDCIssuer != "":
+			verifier = NewKeylessVerifier(*certIdentity, *certOIDCIssuer)
+		default:
+			// No key or identity configured: still require a signature
+			// artifact to exist and name the right digest, but don't commit
+			// to a trust model.
+			verifier = noopVerifier{}
+		}
+	}
+
+	limiter := newRateLimiter(*rps, *burst)
+	defer limiter.Close()
+
+	var client Searcher
+	var nexusClient *NexusSearch
+	switch *backend {
+	case "nexus":
+		if *repository == "" {
+			fmt.Println("Error: --repository is required for the nexus backend")
+			os.Exit(1)
+		}
+		nexusClient = NewNexusSearch(*url, *repository, *username, *password, !*noVerifySSL, *verbose, *format, scheme, *keep, *platform, verifier, *concurrency, limiter)
+		client = nexusClient
+	case "registry-v2":
+		client = NewRegistryV2Search(*url, *repository, *username, *password, !*noVerifySSL, *verbose, scheme, *keep, *platform, verifier, limiter)
+	default:
+		fmt.Printf("Error: unknown backend %q (want nexus or registry-v2)\n", *backend)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	var outputData interface{}
+	if *prune {
+		report, err := nexusClient.Prune(ctx, patterns, PruneOptions{
+			DryRun:     *dryRun,
+			MinAge:     *minAge,
+			KeepLatest: *keepLatest,
+			Exclude:    *exclude,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		outputData = report
+	} else if *legacyOutput {
+		results, err := client.SearchImages(ctx, patterns)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		outputData = results
+	} else {
+		detailed, ok := client.(DetailedSearcher)
+		if !ok {
+			fmt.Printf("Error: backend %q doesn't support structured output, pass --legacy-output\n", *backend)
+			os.Exit(1)
+		}
+		results, err := detailed.SearchImagesDetailed(ctx, patterns)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		outputData = results
+	}
+
 	// Output results
-	jsonData, err := json.MarshalIndent(results, "", "  ")
+	jsonData, err := json.MarshalIndent(outputData, "", "  ")
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,7 +9,7 @@ import (
 	"testing"
 )
 
-func TestNexusDockerSearch(t *testing.T) {
+func TestNexusSearch(t *testing.T) {
 	// Mock server to simulate Nexus responses
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify request method
@@ -80,7 +81,7 @@ func TestNexusDockerSearch(t *testing.T) {
 	defer server.Close()
 
 	// Create client with test server URL
-	client := NewNexusDockerSearch(server.URL, "test-repo", "", "", true, false)
+	client := NewNexusSearch(server.URL, "test-repo", "", "", true, false, formatDocker, tagSchemeSemver, 2, "", nil, 1, nil)
 
 	// Test cases
 	tests := []struct {
@@ -107,7 +108,7 @@ func TestNexusDockerSearch(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := client.SearchImages(tt.patterns)
+			got, err := client.SearchImages(context.Background(), tt.patterns)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SearchImages() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -124,48 +125,107 @@ func TestNexusDockerSearch(t *testing.T) {
 }
 
 func TestFilterTags(t *testing.T) {
-	client := NewNexusDockerSearch("http://example.com", "test-repo", "", "", true, false)
-
 	tests := []struct {
-		name          string
-		tags          []string
-		latestDigest  string
-		versionDigest string
-		want          []string
+		name    string
+		tags    []string
+		digests map[string]string
+		scheme  string
+		keep    int
+		want    []string
 	}{
 		{
-			name:          "Latest matches highest version",
-			tags:          []string{"latest", "1", "2"},
-			latestDigest:  "sha256-2",
-			versionDigest: "sha256-2",
-			want:          []string{"latest", "2", "1"},
+			name:    "Latest matches highest version",
+			tags:    []string{"latest", "1", "2"},
+			digests: map[string]string{"latest": "sha256-2", "2": "sha256-2", "1": "sha256-1"},
+			scheme:  tagSchemeSemver,
+			keep:    2,
+			want:    []string{"latest", "2", "1"},
+		},
+		{
+			name:    "Latest doesn't match highest version",
+			tags:    []string{"latest", "1", "2"},
+			digests: map[string]string{"latest": "sha256-old", "2": "sha256-new", "1": "sha256-1"},
+			scheme:  tagSchemeSemver,
+			keep:    2,
+			want:    []string{"2", "1"},
+		},
+		{
+			name:    "No latest tag, keep trims to N",
+			tags:    []string{"1", "2", "3"},
+			digests: map[string]string{"1": "sha256-1", "2": "sha256-2", "3": "sha256-3"},
+			scheme:  tagSchemeSemver,
+			keep:    2,
+			want:    []string{"3", "2"},
+		},
+		{
+			name:    "Semver prerelease sorts below release",
+			tags:    []string{"2.0.0-rc1", "1.9.0", "2.0.0"},
+			digests: map[string]string{"2.0.0-rc1": "sha256-rc1", "1.9.0": "sha256-190", "2.0.0": "sha256-200"},
+			scheme:  tagSchemeSemver,
+			keep:    3,
+			want:    []string{"2.0.0", "2.0.0-rc1", "1.9.0"},
+		},
+		{
+			name:    "Calver scheme sorts by date components",
+			tags:    []string{"2024.1.5", "2024.11.5", "2023.12.31"},
+			digests: map[string]string{},
+			scheme:  tagSchemeCalver,
+			keep:    3,
+			want:    []string{"2024.11.5", "2024.1.5", "2023.12.31"},
+		},
+		{
+			name:    "Unparsable tags are dropped, not errored",
+			tags:    []string{"1", "not-a-version", "2"},
+			digests: map[string]string{"1": "sha256-1", "2": "sha256-2"},
+			scheme:  tagSchemeNumeric,
+			keep:    2,
+			want:    []string{"2", "1"},
+		},
+		{
+			name:    "Empty tags",
+			tags:    []string{},
+			digests: map[string]string{},
+			scheme:  tagSchemeSemver,
+			keep:    2,
+			want:    nil,
+		},
+		{
+			name:    "Maven scheme: release outranks SNAPSHOT",
+			tags:    []string{"1.0-SNAPSHOT", "1.0", "1.0.1"},
+			digests: map[string]string{},
+			scheme:  tagSchemeMaven,
+			keep:    3,
+			want:    []string{"1.0.1", "1.0", "1.0-SNAPSHOT"},
 		},
 		{
-			name:          "Latest doesn't match highest version",
-			tags:          []string{"latest", "1", "2"},
-			latestDigest:  "sha256-old",
-			versionDigest: "sha256-new",
-			want:          []string{"2", "1"},
+			name:    "Pep440 scheme: dev < rc < release < post",
+			tags:    []string{"1.0.dev1", "1.0rc1", "1.0", "1.0.post1"},
+			digests: map[string]string{},
+			scheme:  tagSchemePep440,
+			keep:    4,
+			want:    []string{"1.0.post1", "1.0", "1.0rc1", "1.0.dev1"},
 		},
 		{
-			name:          "No latest tag",
-			tags:          []string{"1", "2", "3"},
-			latestDigest:  "",
-			versionDigest: "sha256-1",
-			want:          []string{"3", "2"},
+			name:    "Pep440 scheme: dev release of a post release sits between the base release and its finalized post",
+			tags:    []string{"1.0.post1", "1.0", "1.0.post1.dev1"},
+			digests: map[string]string{},
+			scheme:  tagSchemePep440,
+			keep:    3,
+			want:    []string{"1.0.post1", "1.0.post1.dev1", "1.0"},
 		},
 		{
-			name:          "Empty tags",
-			tags:          []string{},
-			latestDigest:  "",
-			versionDigest: "",
-			want:          nil,
+			name:    "Pep440 scheme: post release of a prerelease outranks the bare prerelease",
+			tags:    []string{"1.0a1", "1.0a1.post1"},
+			digests: map[string]string{},
+			scheme:  tagSchemePep440,
+			keep:    2,
+			want:    []string{"1.0a1.post1", "1.0a1"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := client.filterTags(tt.tags, tt.latestDigest, tt.versionDigest)
+			got := filterTags(tt.tags, tt.digests, tt.scheme, tt.keep, nil)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("filterTags() = %v, want %v", got, tt.want)
 			}
@@ -174,8 +234,6 @@ func TestFilterTags(t *testing.T) {
 }
 
 func TestProcessImages(t *testing.T) {
-	client := NewNexusDockerSearch("http://example.com", "test-repo", "", "", true, false)
-
 	tests := []struct {
 		name   string
 		images []map[string]string
@@ -212,7 +270,7 @@ func TestProcessImages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := client.processImages(tt.images)
+			got, err := processImages(tt.images, false, tagSchemeSemver, 2)
 			if err != nil {
 				t.Errorf("processImages() error = %v", err)
 				return
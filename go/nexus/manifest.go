@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Manifest media types we ask registries to return. Single-platform
+// manifests give us a real content digest; manifest lists and OCI image
+// indexes additionally tell us which digest belongs to which platform.
+const (
+	mediaTypeDockerManifestV2   = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIImageIndex      = "application/vnd.oci.image.index.v1+json"
+)
+
+var manifestAcceptTypes = []string{
+	mediaTypeDockerManifestV2,
+	mediaTypeOCIManifest,
+	mediaTypeDockerManifestList,
+	mediaTypeOCIImageIndex,
+}
+
+// Platform describes one entry of a multi-arch manifest list or OCI image index.
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+	Digest       string `json:"digest"`
+	Size         int64  `json:"size"`
+}
+
+// DetailedSearcher is implemented by backends that can report multi-arch
+// manifest structure per tag. Searcher itself stays tag-name-only so
+// --legacy-output and callers like Prune, which only need names, don't pay
+// for manifest fetches they don't use.
+type DetailedSearcher interface {
+	SearchImagesDetailed(ctx context.Context, patterns []string) (map[string][]TagInfo, error)
+}
+
+// TagInfo is the structured, multi-arch-aware description of one kept tag,
+// returned by SearchImagesDetailed in place of a bare tag name.
+type TagInfo struct {
+	Tag       string     `json:"tag"`
+	IsIndex   bool       `json:"isIndex"`
+	Platforms []Platform `json:"platforms,omitempty"`
+	TopDigest string     `json:"topDigest"`
+}
+
+// manifestInfo is the parsed result of fetching one name:ref manifest.
+type manifestInfo struct {
+	mediaType string
+	digest    string
+	platforms []Platform
+}
+
+// parseManifestBody parses a manifest (or manifest-list/OCI-index) response
+// body. digest is the registry-reported content digest of the manifest
+// itself (from the Docker-Content-Digest response header).
+func parseManifestBody(body []byte, digest string) (manifestInfo, error) {
+	var parsed struct {
+		MediaType string `json:"mediaType"`
+		Manifests []struct {
+			Digest   string `json:"digest"`
+			Size     int64  `json:"size"`
+			Platform struct {
+				Architecture string `json:"architecture"`
+				OS           string `json:"os"`
+				Variant      string `json:"variant"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return manifestInfo{}, fmt.Errorf("failed to parse manifest body: %v", err)
+	}
+
+	info := manifestInfo{mediaType: parsed.MediaType, digest: digest}
+	if info.isIndex() {
+		for _, m := range parsed.Manifests {
+			info.platforms = append(info.platforms, Platform{
+				OS:           m.Platform.OS,
+				Architecture: m.Platform.Architecture,
+				Variant:      m.Platform.Variant,
+				Digest:       strings.TrimPrefix(m.Digest, "sha256:"),
+				Size:         m.Size,
+			})
+		}
+	}
+
+	return info, nil
+}
+
+func (m manifestInfo) isIndex() bool {
+	return m.mediaType == mediaTypeDockerManifestList || m.mediaType == mediaTypeOCIImageIndex
+}
+
+// platformDigest returns the digest of the platform entry matching
+// "os/architecture[/variant]" (e.g. "linux/amd64"), or ("", false) if
+// platform is empty, m isn't a manifest list/index, or no entry matches.
+func (m manifestInfo) platformDigest(platform string) (string, bool) {
+	if platform == "" {
+		return "", false
+	}
+	for _, p := range m.platforms {
+		candidate := p.OS + "/" + p.Architecture
+		if p.Variant != "" {
+			candidate += "/" + p.Variant
+		}
+		if candidate == platform {
+			return p.Digest, true
+		}
+	}
+	return "", false
+}
+
+// buildDetailedResults narrows the full manifest scan down to the kept tags
+// processImages selected, attaching each one's multi-arch manifest info.
+func buildDetailedResults(kept map[string][]string, manifests map[string]manifestInfo) map[string][]TagInfo {
+	results := make(map[string][]TagInfo, len(kept))
+	for name, tags := range kept {
+		for _, tag := range tags {
+			info := manifests[name+":"+tag]
+			results[name] = append(results[name], TagInfo{
+				Tag:       tag,
+				IsIndex:   info.isIndex(),
+				Platforms: info.platforms,
+				TopDigest: info.digest,
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseManifestBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		digest  string
+		want    manifestInfo
+		wantErr bool
+	}{
+		{
+			name:   "Single-platform manifest",
+			body:   `{"mediaType": "application/vnd.docker.distribution.manifest.v2+json"}`,
+			digest: "sha256-single",
+			want: manifestInfo{
+				mediaType: mediaTypeDockerManifestV2,
+				digest:    "sha256-single",
+			},
+		},
+		{
+			name: "Docker manifest list",
+			body: `{
+				"mediaType": "application/vnd.docker.distribution.manifest.list.v2+json",
+				"manifests": [
+					{"digest": "sha256:amd64-digest", "size": 100, "platform": {"architecture": "amd64", "os": "linux"}},
+					{"digest": "sha256:arm64-digest", "size": 100, "platform": {"architecture": "arm64", "os": "linux"}}
+				]
+			}`,
+			digest: "sha256-index",
+			want: manifestInfo{
+				mediaType: mediaTypeDockerManifestList,
+				digest:    "sha256-index",
+				platforms: []Platform{
+					{OS: "linux", Architecture: "amd64", Digest: "amd64-digest", Size: 100},
+					{OS: "linux", Architecture: "arm64", Digest: "arm64-digest", Size: 100},
+				},
+			},
+		},
+		{
+			name:    "Invalid JSON",
+			body:    `not json`,
+			digest:  "sha256-bad",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseManifestBody([]byte(tt.body), tt.digest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseManifestBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseManifestBody() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManifestInfoPlatformDigest(t *testing.T) {
+	index := manifestInfo{
+		mediaType: mediaTypeOCIImageIndex,
+		platforms: []Platform{
+			{OS: "linux", Architecture: "amd64", Digest: "amd64-digest"},
+			{OS: "linux", Architecture: "arm64", Variant: "v8", Digest: "arm64-digest"},
+		},
+	}
+	single := manifestInfo{mediaType: mediaTypeDockerManifestV2, digest: "sha256-single"}
+
+	tests := []struct {
+		name       string
+		info       manifestInfo
+		platform   string
+		wantDigest string
+		wantOK     bool
+	}{
+		{"No platform requested", index, "", "", false},
+		{"Matching platform", index, "linux/amd64", "amd64-digest", true},
+		{"Matching platform with variant", index, "linux/arm64/v8", "arm64-digest", true},
+		{"Unknown platform", index, "linux/386", "", false},
+		{"Not an index", single, "linux/amd64", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest, ok := tt.info.platformDigest(tt.platform)
+			if digest != tt.wantDigest || ok != tt.wantOK {
+				t.Errorf("platformDigest(%q) = (%q, %v), want (%q, %v)", tt.platform, digest, ok, tt.wantDigest, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBuildDetailedResults(t *testing.T) {
+	kept := map[string][]string{
+		"test/image1": {"2", "1"},
+	}
+	manifests := map[string]manifestInfo{
+		"test/image1:2": {
+			mediaType: mediaTypeDockerManifestList,
+			digest:    "sha256-index-2",
+			platforms: []Platform{{OS: "linux", Architecture: "amd64", Digest: "amd64-2"}},
+		},
+		"test/image1:1": {
+			mediaType: mediaTypeDockerManifestV2,
+			digest:    "sha256-single-1",
+		},
+	}
+
+	got := buildDetailedResults(kept, manifests)
+	want := map[string][]TagInfo{
+		"test/image1": {
+			{
+				Tag:       "2",
+				IsIndex:   true,
+				Platforms: []Platform{{OS: "linux", Architecture: "amd64", Digest: "amd64-2"}},
+				TopDigest: "sha256-index-2",
+			},
+			{
+				Tag:       "1",
+				IsIndex:   false,
+				TopDigest: "sha256-single-1",
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildDetailedResults() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+)
+
+// PruneOptions configures which tags Prune deletes once SearchImages-style
+// filtering has decided which tags to keep.
+type PruneOptions struct {
+	DryRun     bool          // report what would be deleted without deleting
+	MinAge     time.Duration // skip components newer than this (zero disables the guard)
+	KeepLatest bool          // never delete a component tagged "latest"
+	Exclude    string        // path.Match pattern; matching tags are never deleted
+}
+
+// PruneAction describes one component that was (or would have been) deleted.
+type PruneAction struct {
+	Name    string `json:"name"`
+	Tag     string `json:"tag"`
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// PruneReport is the JSON summary emitted for --prune.
+type PruneReport struct {
+	DryRun  bool          `json:"dryRun"`
+	Pruned  []PruneAction `json:"pruned"`
+	Kept    int           `json:"kept"`
+	Skipped int           `json:"skipped"`
+}
+
+// Prune searches for patterns exactly like SearchImages, but instead of
+// just returning the kept tags it deletes (or, with DryRun, reports) every
+// matching component whose tag didn't make the cut.
+func (n *NexusSearch) Prune(ctx context.Context, patterns []string, opts PruneOptions) (*PruneReport, error) {
+	components, err := n.searchComponents(ctx, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	kept, err := processImages(components, n.verbose, n.tagScheme, n.keep)
+	if err != nil {
+		return nil, err
+	}
+
+	keptTags := make(map[string]map[string]bool, len(kept))
+	for name, tags := range kept {
+		set := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			set[tag] = true
+		}
+		keptTags[name] = set
+	}
+
+	// An image whose tags *all* fail to parse under --tag-scheme (e.g. the
+	// user left the default "semver" on a repo with non-semver tags) is
+	// dropped from kept entirely, which would otherwise make every one of
+	// its tags look like a delete candidate. Treat that as a scheme
+	// mismatch rather than "nothing worth keeping" and protect the whole
+	// image instead of silently pruning it.
+	mismatched := schemeMismatchImages(components, n.tagScheme)
+
+	report := &PruneReport{DryRun: opts.DryRun}
+
+	for _, component := range components {
+		name := component["name"]
+		tag := component["version"]
+		id := component["id"]
+
+		if keptTags[name][tag] {
+			report.Kept++
+			continue
+		}
+
+		if mismatched[name] {
+			fmt.Printf("Keeping %s:%s: no tags for this image parse under --tag-scheme %s, refusing to prune\n", name, tag, n.tagScheme)
+			report.Skipped++
+			continue
+		}
+
+		if opts.KeepLatest && tag == "latest" {
+			if n.verbose {
+				fmt.Printf("Keeping %s:%s: matches --keep-latest\n", name, tag)
+			}
+			report.Skipped++
+			continue
+		}
+
+		if opts.Exclude != "" {
+			if ok, err := path.Match(opts.Exclude, tag); err == nil && ok {
+				if n.verbose {
+					fmt.Printf("Keeping %s:%s: matches --exclude %q\n", name, tag, opts.Exclude)
+				}
+				report.Skipped++
+				continue
+			}
+		}
+
+		if opts.MinAge > 0 {
+			lastModified, err := time.Parse(time.RFC3339, component["lastModified"])
+			if err != nil {
+				if n.verbose {
+					fmt.Printf("Keeping %s:%s: lastModified %q is missing or unparseable, protecting\n", name, tag, component["lastModified"])
+				}
+				report.Skipped++
+				continue
+			}
+			if time.Since(lastModified) < opts.MinAge {
+				if n.verbose {
+					fmt.Printf("Keeping %s:%s: younger than --min-age\n", name, tag)
+				}
+				report.Skipped++
+				continue
+			}
+		}
+
+		if id == "" {
+			if n.verbose {
+				fmt.Printf("Skipping %s:%s: no component id returned by search\n", name, tag)
+			}
+			report.Skipped++
+			continue
+		}
+
+		action := PruneAction{Name: name, Tag: tag, ID: id}
+		if !opts.DryRun {
+			if err := n.deleteComponent(ctx, id); err != nil {
+				return nil, fmt.Errorf("failed to delete %s:%s (id %s): %v", name, tag, id, err)
+			}
+			action.Deleted = true
+		}
+
+		report.Pruned = append(report.Pruned, action)
+	}
+
+	return report, nil
+}
+
+// schemeMismatchImages returns the set of image names in components for
+// which every non-"latest" tag fails to parse under scheme. Prune treats
+// these as a --tag-scheme mismatch rather than "zero tags worth keeping",
+// since the latter would otherwise make every tag of the image a delete
+// candidate.
+func schemeMismatchImages(components []map[string]string, scheme string) map[string]bool {
+	tagsByName := make(map[string][]string)
+	for _, component := range components {
+		name := component["name"]
+		if tag := component["version"]; tag != "latest" {
+			tagsByName[name] = append(tagsByName[name], tag)
+		}
+	}
+
+	mismatched := make(map[string]bool)
+	for name, tags := range tagsByName {
+		if len(tags) == 0 {
+			continue
+		}
+		ranked, _ := rankTags(tags, scheme)
+		if len(ranked) == 0 {
+			mismatched[name] = true
+		}
+	}
+	return mismatched
+}
+
+// deleteComponent issues DELETE /service/rest/v1/components/{id}.
+func (n *NexusSearch) deleteComponent(ctx context.Context, id string) error {
+	url := n.baseURL + "/service/rest/v1/components/" + id
+
+	if n.verbose {
+		fmt.Printf("Deleting component %s\n", id)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+
+	if n.authHeader != "" {
+		req.Header.Add("Authorization", n.authHeader)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	var deleted []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/service/rest/v1/search":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"items": []map[string]interface{}{
+					{
+						"id":      "id-latest",
+						"name":    "test/image1",
+						"version": "latest",
+						"assets": []map[string]interface{}{
+							{"checksum": map[string]string{"sha256": "sha256-3"}, "lastModified": "2026-07-20T00:00:00.000Z"},
+						},
+					},
+					{
+						"id":      "id-3",
+						"name":    "test/image1",
+						"version": "3",
+						"assets": []map[string]interface{}{
+							{"checksum": map[string]string{"sha256": "sha256-3"}, "lastModified": "2026-07-20T00:00:00.000Z"},
+						},
+					},
+					{
+						"id":      "id-2",
+						"name":    "test/image1",
+						"version": "2",
+						"assets": []map[string]interface{}{
+							{"checksum": map[string]string{"sha256": "sha256-2"}, "lastModified": "2026-07-20T00:00:00.000Z"},
+						},
+					},
+					{
+						"id":      "id-1",
+						"name":    "test/image1",
+						"version": "1",
+						"assets": []map[string]interface{}{
+							{"checksum": map[string]string{"sha256": "sha256-1"}, "lastModified": "2026-07-20T00:00:00.000Z"},
+						},
+					},
+				},
+			})
+
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewNexusSearch(server.URL, "test-repo", "", "", true, false, formatDocker, tagSchemeNumeric, 2, "", nil, 1, nil)
+
+	// Dry run: nothing deleted, but tag "1" is reported as prunable.
+	report, err := client.Prune(context.Background(), []string{"test/*"}, PruneOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Prune() dry-run error = %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("dry-run should not delete anything, got %v", deleted)
+	}
+	if len(report.Pruned) != 1 || report.Pruned[0].Tag != "1" || report.Pruned[0].Deleted {
+		t.Fatalf("unexpected dry-run report: %+v", report.Pruned)
+	}
+
+	// Real run: tag "1" actually gets deleted via the components API.
+	report, err = client.Prune(context.Background(), []string{"test/*"}, PruneOptions{DryRun: false})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "/service/rest/v1/components/id-1" {
+		t.Fatalf("expected id-1 to be deleted, got %v", deleted)
+	}
+	if !report.Pruned[0].Deleted {
+		t.Fatalf("expected report to mark tag as deleted")
+	}
+}
+
+func TestPruneMinAgeUnparseableProtects(t *testing.T) {
+	var deleted []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/service/rest/v1/search":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"items": []map[string]interface{}{
+					{
+						"id":      "id-2",
+						"name":    "test/image1",
+						"version": "2",
+						"assets": []map[string]interface{}{
+							{"checksum": map[string]string{"sha256": "sha256-2"}, "lastModified": "2026-07-20T00:00:00.000Z"},
+						},
+					},
+					{
+						"id":      "id-1",
+						"name":    "test/image1",
+						"version": "1",
+						"assets": []map[string]interface{}{
+							{"checksum": map[string]string{"sha256": "sha256-1"}},
+						},
+					},
+				},
+			})
+
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewNexusSearch(server.URL, "test-repo", "", "", true, false, formatDocker, tagSchemeNumeric, 1, "", nil, 1, nil)
+
+	// Tag "1" has no lastModified and must be protected rather than
+	// treated as old enough to delete.
+	report, err := client.Prune(context.Background(), []string{"test/*"}, PruneOptions{DryRun: false, MinAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Prune() with --min-age error = %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("tag with missing/unparseable lastModified must not be deleted, got %v", deleted)
+	}
+	if len(report.Pruned) != 0 || report.Skipped == 0 {
+		t.Fatalf("expected tag 1 to be skipped as protected, got report %+v", report)
+	}
+}
+
+func TestPruneSchemeMismatchProtectsWholeImage(t *testing.T) {
+	var deleted []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/service/rest/v1/search":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"items": []map[string]interface{}{
+					{
+						"id":      "id-a",
+						"name":    "test/image1",
+						"version": "release-a",
+						"assets": []map[string]interface{}{
+							{"checksum": map[string]string{"sha256": "sha256-a"}, "lastModified": "2026-07-20T00:00:00.000Z"},
+						},
+					},
+					{
+						"id":      "id-b",
+						"name":    "test/image1",
+						"version": "release-b",
+						"assets": []map[string]interface{}{
+							{"checksum": map[string]string{"sha256": "sha256-b"}, "lastModified": "2026-07-20T00:00:00.000Z"},
+						},
+					},
+				},
+			})
+
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	// tagScheme=semver but neither tag parses as semver: the whole image
+	// must be protected instead of treated as "nothing worth keeping".
+	client := NewNexusSearch(server.URL, "test-repo", "", "", true, false, formatDocker, tagSchemeSemver, 1, "", nil, 1, nil)
+
+	report, err := client.Prune(context.Background(), []string{"test/*"}, PruneOptions{DryRun: false})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("scheme-mismatched image must not be pruned, got %v", deleted)
+	}
+	if len(report.Pruned) != 0 || report.Skipped != 2 {
+		t.Fatalf("expected both tags to be skipped as scheme-mismatched, got report %+v", report)
+	}
+}
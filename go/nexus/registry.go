@@ -0,0 +1,499 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bearerChallenge is a parsed `WWW-Authenticate: Bearer realm=...,service=...,scope=...` header.
+type bearerChallenge struct {
+	realm   string
+	service string
+	scope   string
+}
+
+var bearerParamRe = regexp.MustCompile(`([a-zA-Z]+)="([^"]*)"`)
+
+func parseBearerChallenge(header string) (bearerChallenge, bool) {
+	if !strings.HasPrefix(header, "Bearer ") {
+		return bearerChallenge{}, false
+	}
+
+	var c bearerChallenge
+	for _, m := range bearerParamRe.FindAllStringSubmatch(header, -1) {
+		switch m[1] {
+		case "realm":
+			c.realm = m[2]
+		case "service":
+			c.service = m[2]
+		case "scope":
+			c.scope = m[2]
+		}
+	}
+
+	return c, c.realm != ""
+}
+
+// RegistryV2Search implements Searcher against the OCI/Docker Registry HTTP
+// API v2 (https://distribution.github.io/distribution/spec/api/), so the
+// same pattern matching and processImages pipeline used for Nexus also
+// works against Harbor, GHCR, ECR or a plain distribution registry.
+type RegistryV2Search struct {
+	baseURL          string
+	repositoryPrefix string
+	username         string
+	password         string
+	verbose          bool
+	tagScheme        string
+	keep             int
+	platform         string
+	httpClient       *http.Client
+	verifier         Verifier
+	limiter          *rateLimiter
+
+	tokenMu sync.Mutex
+	tokens  map[string]cachedToken
+}
+
+type cachedToken struct {
+	value   string
+	expires time.Time
+}
+
+// NewRegistryV2Search creates a new RegistryV2Search client. repositoryPrefix,
+// when set, restricts the catalog walk to repository names with that prefix
+// (useful on registries that namespace unrelated projects under one host).
+// tagScheme and keep configure tag ranking exactly as they do for
+// NewNexusSearch. platform, if set (e.g. "linux/amd64"), picks which
+// manifest-list entry's digest is used for ranking and the "latest matches
+// top version" check; empty means use the manifest (list)'s own digest.
+// verifier, if non-nil, is consulted for every kept tag's cosign signature
+// (see --require-signature in main); nil skips signature verification.
+// limiter, if non-nil, throttles the rate of outgoing requests (see
+// --rps/--burst in main); nil means unlimited.
+func NewRegistryV2Search(baseURL, repositoryPrefix, username, password string, verifySSL, verbose bool, tagScheme string, keep int, platform string, verifier Verifier, limiter *rateLimiter) *RegistryV2Search {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: !verifySSL,
+		},
+	}
+
+	return &RegistryV2Search{
+		baseURL:          strings.TrimRight(baseURL, "/"),
+		repositoryPrefix: repositoryPrefix,
+		username:         username,
+		password:         password,
+		verbose:          verbose,
+		tagScheme:        tagScheme,
+		keep:             keep,
+		platform:         platform,
+		httpClient:       &http.Client{Transport: transport},
+		verifier:         verifier,
+		limiter:          limiter,
+		tokens:           make(map[string]cachedToken),
+	}
+}
+
+// doRequest performs req, transparently handling the Bearer token challenge:
+// on a 401 with a WWW-Authenticate: Bearer header it fetches (or reuses a
+// cached) token for the requested scope and retries the request once.
+// ctx, in addition to whatever req.Context() already carries, throttles the
+// request through r.limiter.
+func (r *RegistryV2Search) doRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if err := r.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	challenge, ok := parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))
+	if !ok {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	token, err := r.fetchToken(ctx, challenge)
+	if err != nil {
+		return nil, fmt.Errorf("bearer token negotiation failed: %v", err)
+	}
+
+	retry := req.Clone(req.Context())
+	retry.Header.Set("Authorization", "Bearer "+token)
+
+	if r.verbose {
+		fmt.Printf("Retrying %s with negotiated bearer token for scope %q\n", req.URL, challenge.scope)
+	}
+
+	return r.httpClient.Do(retry)
+}
+
+// fetchToken requests a token from the challenge's realm and caches it by
+// scope so subsequent requests in the same scope skip the 401 round trip.
+func (r *RegistryV2Search) fetchToken(ctx context.Context, c bearerChallenge) (string, error) {
+	r.tokenMu.Lock()
+	if cached, ok := r.tokens[c.scope]; ok && time.Now().Before(cached.expires) {
+		r.tokenMu.Unlock()
+		return cached.value, nil
+	}
+	r.tokenMu.Unlock()
+
+	params := url.Values{}
+	if c.service != "" {
+		params.Set("service", c.service)
+	}
+	if c.scope != "" {
+		params.Set("scope", c.scope)
+	}
+
+	tokenURL := c.realm
+	if len(params) > 0 {
+		tokenURL += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", tokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %v", err)
+	}
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	if r.verbose {
+		fmt.Printf("Negotiating bearer token for scope %q at %s\n", c.scope, c.realm)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %v", err)
+	}
+
+	var parsed struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %v", err)
+	}
+
+	token := parsed.Token
+	if token == "" {
+		token = parsed.AccessToken
+	}
+	if token == "" {
+		return "", fmt.Errorf("token endpoint response had no token or access_token field")
+	}
+
+	expiresIn := parsed.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = 60
+	}
+
+	r.tokenMu.Lock()
+	r.tokens[c.scope] = cachedToken{value: token, expires: time.Now().Add(time.Duration(expiresIn) * time.Second)}
+	r.tokenMu.Unlock()
+
+	return token, nil
+}
+
+// getJSON issues an authenticated GET against the registry and decodes a JSON body.
+func (r *RegistryV2Search) getJSON(ctx context.Context, reqURL string, out interface{}) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	if r.verbose {
+		fmt.Printf("Making request to: %s\n", reqURL)
+	}
+
+	resp, err := r.doRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return resp, fmt.Errorf("failed to parse JSON response: %v", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// nextLink extracts the repository-next path from a paginated response's
+// Link header, e.g. `</v2/_catalog?last=foo&n=100>; rel="next"`.
+func nextLink(resp *http.Response) string {
+	link := resp.Header.Get("Link")
+	if link == "" {
+		return ""
+	}
+	parts := strings.SplitN(link, ";", 2)
+	target := strings.TrimSpace(parts[0])
+	return strings.Trim(target, "<>")
+}
+
+// catalog lists every repository name in the registry, following Link-header pagination.
+func (r *RegistryV2Search) catalog(ctx context.Context) ([]string, error) {
+	var repos []string
+
+	next := "/v2/_catalog?n=100"
+	for next != "" {
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+		resp, err := r.getJSON(ctx, r.baseURL+next, &page)
+		if err != nil {
+			return nil, fmt.Errorf("catalog request failed: %v", err)
+		}
+		repos = append(repos, page.Repositories...)
+		next = nextLink(resp)
+	}
+
+	return repos, nil
+}
+
+// tagsList lists every tag for a repository, following Link-header pagination.
+func (r *RegistryV2Search) tagsList(ctx context.Context, repo string) ([]string, error) {
+	var tags []string
+
+	next := "/v2/" + repo + "/tags/list?n=100"
+	for next != "" {
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		resp, err := r.getJSON(ctx, r.baseURL+next, &page)
+		if err != nil {
+			return nil, fmt.Errorf("tags list request failed for %s: %v", repo, err)
+		}
+		tags = append(tags, page.Tags...)
+		next = nextLink(resp)
+	}
+
+	return tags, nil
+}
+
+// fetchManifestInfo fetches the manifest for name:ref. If it's a manifest
+// list or OCI image index, the returned manifestInfo carries the per-platform
+// manifests too; otherwise it's a single-platform manifest and platforms is
+// empty.
+func (r *RegistryV2Search) fetchManifestInfo(ctx context.Context, name, ref string) (manifestInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+"/v2/"+name+"/manifests/"+ref, nil)
+	if err != nil {
+		return manifestInfo{}, fmt.Errorf("failed to create manifest request: %v", err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestAcceptTypes, ", "))
+
+	if r.verbose {
+		fmt.Printf("Fetching manifest for %s:%s\n", name, ref)
+	}
+
+	resp, err := r.doRequest(ctx, req)
+	if err != nil {
+		return manifestInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return manifestInfo{}, fmt.Errorf("manifest request for %s:%s returned HTTP %d", name, ref, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return manifestInfo{}, fmt.Errorf("failed to read manifest body: %v", err)
+	}
+
+	digest := strings.TrimPrefix(resp.Header.Get("Docker-Content-Digest"), "sha256:")
+	return parseManifestBody(body, digest)
+}
+
+// fetchRawManifest fetches the manifest for name:ref and returns its raw
+// body, unparsed. Signature lookup (verifyTagSignature) uses this to read a
+// cosign signature artifact's layers/annotations, which manifestInfo doesn't
+// carry.
+func (r *RegistryV2Search) fetchRawManifest(ctx context.Context, name, ref string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+"/v2/"+name+"/manifests/"+ref, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest request: %v", err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestAcceptTypes, ", "))
+
+	resp, err := r.doRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("manifest request for %s:%s returned HTTP %d", name, ref, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// fetchBlob fetches a content-addressed blob (e.g. a signature's signed
+// payload) by digest, which must include its algorithm prefix (e.g. "sha256:...").
+func (r *RegistryV2Search) fetchBlob(ctx context.Context, name, digest string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+"/v2/"+name+"/blobs/"+digest, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob request: %v", err)
+	}
+
+	resp, err := r.doRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("blob request for %s@%s returned HTTP %d", name, digest, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// scan lists the registry's catalog, matches repository names against
+// patterns client-side (the v2 API has no server-side name search), then
+// fetches tags and manifest info for every match. It returns both the flat
+// (name, version, sha256) tuples processImages expects and the manifestInfo
+// behind each of them, keyed by "name:tag", for SearchImagesDetailed.
+func (r *RegistryV2Search) scan(ctx context.Context, patterns []string) ([]map[string]string, map[string]manifestInfo, error) {
+	if r.verbose {
+		fmt.Printf("Searching for patterns: %v\n", patterns)
+	}
+
+	repos, err := r.catalog(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matchingImages := make([]map[string]string, 0)
+	manifests := make(map[string]manifestInfo)
+
+	for _, repo := range repos {
+		if r.repositoryPrefix != "" && !strings.HasPrefix(repo, r.repositoryPrefix) {
+			continue
+		}
+		if !matchesAnyPattern(repo, patterns) {
+			continue
+		}
+
+		tags, err := r.tagsList(ctx, repo)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, tag := range tags {
+			info, err := r.fetchManifestInfo(ctx, repo, tag)
+			if err != nil {
+				if r.verbose {
+					fmt.Printf("Skipping %s:%s: %v\n", repo, tag, err)
+				}
+				continue
+			}
+			manifests[repo+":"+tag] = info
+
+			digest := info.digest
+			if platformDigest, ok := info.platformDigest(r.platform); ok {
+				digest = platformDigest
+			}
+
+			matchingImages = append(matchingImages, map[string]string{
+				"name":    repo,
+				"version": tag,
+				"sha256":  digest,
+			})
+		}
+	}
+
+	if r.verbose {
+		fmt.Printf("Total matching images found: %d\n", len(matchingImages))
+	}
+
+	return matchingImages, manifests, nil
+}
+
+// SearchImages implements Searcher's legacy tag-name-only shape.
+func (r *RegistryV2Search) SearchImages(ctx context.Context, patterns []string) (map[string][]string, error) {
+	components, _, err := r.scan(ctx, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	kept, err := processImages(components, r.verbose, r.tagScheme, r.keep)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.verifier != nil {
+		kept = filterVerified(ctx, r, r.verifier, kept, buildDigestIndex(components))
+	}
+
+	return kept, nil
+}
+
+// SearchImagesDetailed is like SearchImages but reports multi-arch manifest
+// list / OCI image index structure per kept tag instead of just its name.
+func (r *RegistryV2Search) SearchImagesDetailed(ctx context.Context, patterns []string) (map[string][]TagInfo, error) {
+	components, manifests, err := r.scan(ctx, patterns)
+	if err != nil {
+		return nil, err
+	}
+	kept, err := processImages(components, r.verbose, r.tagScheme, r.keep)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.verifier != nil {
+		kept = filterVerified(ctx, r, r.verifier, kept, buildDigestIndex(components))
+	}
+
+	return buildDetailedResults(kept, manifests), nil
+}
+
+// matchesAnyPattern reports whether repo matches at least one of the given
+// shell-style patterns (path.Match semantics, e.g. "library/*").
+func matchesAnyPattern(repo string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, repo); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
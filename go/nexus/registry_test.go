@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRegistryV2SearchImages(t *testing.T) {
+	var tokenRequests int
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			tokenRequests++
+			if r.URL.Query().Get("scope") == "" {
+				t.Errorf("expected scope in token request, got none")
+			}
+			fmt.Fprint(w, `{"token": "test-token", "expires_in": 300}`)
+
+		case r.URL.Path == "/v2/_catalog":
+			if r.Header.Get("Authorization") != "Bearer test-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="registry:catalog:*"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"repositories": ["test/image1", "other/image2"]}`)
+
+		case r.URL.Path == "/v2/test/image1/tags/list":
+			fmt.Fprint(w, `{"tags": ["1", "2"]}`)
+
+		case r.URL.Path == "/v2/test/image1/manifests/1":
+			w.Header().Set("Docker-Content-Digest", "sha256:sha256-1")
+			fmt.Fprint(w, `{}`)
+
+		case r.URL.Path == "/v2/test/image1/manifests/2":
+			w.Header().Set("Docker-Content-Digest", "sha256:sha256-2")
+			fmt.Fprint(w, `{}`)
+
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewRegistryV2Search(server.URL, "", "", "", true, false, tagSchemeSemver, 2, "", nil, nil)
+
+	got, err := client.SearchImages(context.Background(), []string{"test/*"})
+	if err != nil {
+		t.Fatalf("SearchImages() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"test/image1": {"2", "1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchImages() = %v, want %v", got, want)
+	}
+
+	if tokenRequests == 0 {
+		t.Errorf("expected at least one bearer token negotiation")
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// cosignSignatureTag returns the tag cosign's "trust on fetch" convention
+// stores a digest's signature artifact under, in the same repository as the
+// image itself.
+func cosignSignatureTag(digest string) string {
+	return "sha256-" + digest + ".sig"
+}
+
+const (
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	cosignCertAnnotation      = "dev.sigstore.cosign/certificate"
+)
+
+// signatureManifest is the subset of a cosign signature artifact's OCI
+// manifest we need: one layer per signature, with the base64 signature and
+// (for the keyless flow) a PEM certificate attached as annotations, and the
+// signed payload as the layer's blob.
+type signatureManifest struct {
+	Layers []struct {
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+// simpleSigningPayload is cosign's "simple signing" document: the thing
+// that actually gets signed. critical.image.docker-manifest-digest names
+// the image digest the signature vouches for, which is what ties a
+// signature found via the cosign tag convention back to the tag it signs.
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// ManifestBlobFetcher is implemented by backends that can fetch a raw
+// manifest body and a blob by digest. Both NexusSearch and
+// RegistryV2Search implement it so verifyTagSignature works against either.
+type ManifestBlobFetcher interface {
+	fetchRawManifest(ctx context.Context, name, ref string) ([]byte, error)
+	fetchBlob(ctx context.Context, name, digest string) ([]byte, error)
+}
+
+// Verifier checks a single cosign signature. StaticKeyVerifier, KeylessVerifier
+// and noopVerifier all implement it so --require-signature can plug in
+// whichever trust model the user configured without the lookup/parsing code
+// in verifyTagSignature caring which one it's using.
+type Verifier interface {
+	// Verify reports whether signature is a valid signature over payload.
+	// cert is the PEM-encoded certificate attached to the signature, if
+	// any (the keyless flow always has one; static-key signing usually
+	// doesn't). Implementations that don't need it may ignore it.
+	Verify(payload, signature, cert []byte) error
+}
+
+// verifyTagSignature locates name@sha256:digest's cosign signature artifact
+// via fetcher and reports nil only if at least one of its attached
+// signatures verifies under v and names digest.
+func verifyTagSignature(ctx context.Context, fetcher ManifestBlobFetcher, v Verifier, name, digest string) error {
+	tag := cosignSignatureTag(digest)
+
+	body, err := fetcher.fetchRawManifest(ctx, name, tag)
+	if err != nil {
+		return fmt.Errorf("no signature artifact found at %s:%s: %v", name, tag, err)
+	}
+
+	var manifest signatureManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return fmt.Errorf("failed to parse signature manifest: %v", err)
+	}
+	if len(manifest.Layers) == 0 {
+		return fmt.Errorf("signature manifest %s:%s has no layers", name, tag)
+	}
+
+	wantDigest := "sha256:" + digest
+	var lastErr error
+	for _, layer := range manifest.Layers {
+		sigB64 := layer.Annotations[cosignSignatureAnnotation]
+		if sigB64 == "" {
+			lastErr = fmt.Errorf("layer %s has no %s annotation", layer.Digest, cosignSignatureAnnotation)
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(sigB64)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to decode signature: %v", err)
+			continue
+		}
+
+		payload, err := fetcher.fetchBlob(ctx, name, layer.Digest)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to fetch signed payload %s: %v", layer.Digest, err)
+			continue
+		}
+
+		var simple simpleSigningPayload
+		if err := json.Unmarshal(payload, &simple); err != nil {
+			lastErr = fmt.Errorf("failed to parse signed payload: %v", err)
+			continue
+		}
+		if simple.Critical.Image.DockerManifestDigest != wantDigest {
+			lastErr = fmt.Errorf("signed payload names digest %q, expected %q", simple.Critical.Image.DockerManifestDigest, wantDigest)
+			continue
+		}
+
+		cert := []byte(layer.Annotations[cosignCertAnnotation])
+		if err := v.Verify(payload, signature, cert); err != nil {
+			lastErr = err
+			continue
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("no valid signature for %s@%s: %v", name, wantDigest, lastErr)
+}
+
+// StaticKeyVerifier implements cosign's "verify with a specific public key"
+// flow (cosign verify --key): every signature must be over the one
+// configured key, regardless of what certificate (if any) it carries.
+type StaticKeyVerifier struct {
+	publicKey crypto.PublicKey
+}
+
+// NewStaticKeyVerifier loads a PEM-encoded public key from path, as produced
+// by `cosign generate-key-pair` or `cosign public-key`.
+func NewStaticKeyVerifier(path string) (*StaticKeyVerifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key %s: %v", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain PEM data", path)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %v", path, err)
+	}
+
+	return &StaticKeyVerifier{publicKey: pub}, nil
+}
+
+func (s *StaticKeyVerifier) Verify(payload, signature, cert []byte) error {
+	return verifyWithPublicKey(s.publicKey, payload, signature)
+}
+
+// fulcioIssuerOID is the x509 extension Fulcio stamps into keyless signing
+// certificates with the signer's OIDC issuer URL.
+var fulcioIssuerOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+
+// KeylessVerifier implements cosign's keyless flow's identity check: the
+// signature's certificate (attached as an annotation, not a file the user
+// supplies) must name certIdentity/certOIDCIssuer, and the signature must
+// verify against that certificate's public key. It does not validate the
+// certificate's chain up to Sigstore's Fulcio root or check Rekor
+// transparency-log inclusion, since both require network calls this
+// package doesn't make, so it catches a forged or misattributed signer but
+// isn't full Sigstore trust.
+type KeylessVerifier struct {
+	certIdentity   string
+	certOIDCIssuer string
+}
+
+// NewKeylessVerifier creates a KeylessVerifier. Either argument may be empty
+// to skip that check, though leaving both empty defeats the point of the
+// keyless flow (any certificate's signature would verify).
+func NewKeylessVerifier(certIdentity, certOIDCIssuer string) *KeylessVerifier {
+	return &KeylessVerifier{certIdentity: certIdentity, certOIDCIssuer: certOIDCIssuer}
+}
+
+func (k *KeylessVerifier) Verify(payload, signature, cert []byte) error {
+	if len(cert) == 0 {
+		return fmt.Errorf("keyless verification requires a signing certificate, signature had none")
+	}
+
+	block, _ := pem.Decode(cert)
+	if block == nil {
+		return fmt.Errorf("signing certificate is not valid PEM")
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse signing certificate: %v", err)
+	}
+
+	if k.certIdentity != "" && !certMatchesIdentity(parsed, k.certIdentity) {
+		return fmt.Errorf("signing certificate identity does not match %q", k.certIdentity)
+	}
+	if k.certOIDCIssuer != "" {
+		issuer, ok := certExtensionValue(parsed, fulcioIssuerOID)
+		if !ok || issuer != k.certOIDCIssuer {
+			return fmt.Errorf("signing certificate OIDC issuer does not match %q", k.certOIDCIssuer)
+		}
+	}
+
+	return verifyWithPublicKey(parsed.PublicKey, payload, signature)
+}
+
+// certMatchesIdentity reports whether cert was issued to identity, checked
+// against both SAN URIs (cosign's usual keyless identity, e.g. a GitHub
+// Actions workflow ref) and SAN email addresses.
+func certMatchesIdentity(cert *x509.Certificate, identity string) bool {
+	for _, uri := range cert.URIs {
+		if uri.String() == identity {
+			return true
+		}
+	}
+	for _, email := range cert.EmailAddresses {
+		if email == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// certExtensionValue returns the string value of cert's extension matching
+// oid. Fulcio encodes the OIDC issuer extension as a DER UTF8String, not a
+// raw string, so ext.Value must be ASN.1-unmarshaled rather than converted
+// directly.
+func certExtensionValue(cert *x509.Certificate, oid asn1.ObjectIdentifier) (string, bool) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oid) {
+			continue
+		}
+		var value string
+		if _, err := asn1.Unmarshal(ext.Value, &value); err != nil {
+			return "", false
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// verifyWithPublicKey checks signature over the sha256 of payload, the
+// digest both cosign's ECDSA and RSA signing modes use.
+func verifyWithPublicKey(publicKey crypto.PublicKey, payload, signature []byte) error {
+	digest := sha256.Sum256(payload)
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return fmt.Errorf("ecdsa signature verification failed")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("rsa signature verification failed: %v", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// noopVerifier accepts any signature without checking it cryptographically.
+// --require-signature falls back to it when neither --cosign-key nor
+// --cert-identity/--cert-oidc-issuer is set, so "a signature artifact
+// exists and names the right digest" is still enforced without committing
+// to a trust model the caller didn't configure.
+type noopVerifier struct{}
+
+func (noopVerifier) Verify(payload, signature, cert []byte) error {
+	return nil
+}
+
+// filterVerified drops every kept tag whose cosign signature doesn't verify
+// under v, logging each drop. digests maps "name:tag" to the digest a
+// signature must name, as built by buildDigestIndex.
+func filterVerified(ctx context.Context, fetcher ManifestBlobFetcher, v Verifier, kept map[string][]string, digests map[string]string) map[string][]string {
+	results := make(map[string][]string, len(kept))
+	for name, tags := range kept {
+		var verified []string
+		for _, tag := range tags {
+			digest := digests[name+":"+tag]
+			if digest == "" {
+				fmt.Printf("Dropping %s:%s: no digest to verify a signature against\n", name, tag)
+				continue
+			}
+			if err := verifyTagSignature(ctx, fetcher, v, name, digest); err != nil {
+				fmt.Printf("Dropping %s:%s: %v\n", name, tag, err)
+				continue
+			}
+			verified = append(verified, tag)
+		}
+		if len(verified) > 0 {
+			results[name] = verified
+		}
+	}
+	return results
+}
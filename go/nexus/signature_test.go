@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeFetcher is an in-memory ManifestBlobFetcher for exercising
+// verifyTagSignature without standing up an HTTP server.
+type fakeFetcher struct {
+	manifests map[string][]byte // keyed by "name:ref"
+	blobs     map[string][]byte // keyed by "name:digest"
+}
+
+func (f *fakeFetcher) fetchRawManifest(ctx context.Context, name, ref string) ([]byte, error) {
+	body, ok := f.manifests[name+":"+ref]
+	if !ok {
+		return nil, fmt.Errorf("no manifest for %s:%s", name, ref)
+	}
+	return body, nil
+}
+
+func (f *fakeFetcher) fetchBlob(ctx context.Context, name, digest string) ([]byte, error) {
+	body, ok := f.blobs[name+":"+digest]
+	if !ok {
+		return nil, fmt.Errorf("no blob %s@%s", name, digest)
+	}
+	return body, nil
+}
+
+// signPayload signs payload's sha256 digest with key and returns the
+// signature, mirroring how cosign's static-key mode signs.
+func signPayload(t *testing.T, key *ecdsa.PrivateKey, payload []byte) []byte {
+	t.Helper()
+	digest := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign payload: %v", err)
+	}
+	return sig
+}
+
+func publicKeyPEM(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func buildFakeFetcher(t *testing.T, name, digest string, payload, signature []byte) *fakeFetcher {
+	t.Helper()
+
+	payloadDigest := sha256.Sum256(payload)
+	payloadRef := fmt.Sprintf("sha256:%x", payloadDigest)
+
+	sigManifest := signatureManifest{
+		Layers: []struct {
+			Digest      string            `json:"digest"`
+			Annotations map[string]string `json:"annotations"`
+		}{
+			{
+				Digest: payloadRef,
+				Annotations: map[string]string{
+					cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature),
+				},
+			},
+		},
+	}
+	manifestBody, err := json.Marshal(sigManifest)
+	if err != nil {
+		t.Fatalf("failed to marshal signature manifest: %v", err)
+	}
+
+	return &fakeFetcher{
+		manifests: map[string][]byte{name + ":" + cosignSignatureTag(digest): manifestBody},
+		blobs:     map[string][]byte{name + ":" + payloadRef: payload},
+	}
+}
+
+func simpleSigningPayloadFor(digest string) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"image":{"docker-manifest-digest":"sha256:%s"}}}`, digest))
+}
+
+func TestVerifyTagSignatureStaticKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(keyPath, publicKeyPEM(t, key), 0644); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	verifier, err := NewStaticKeyVerifier(keyPath)
+	if err != nil {
+		t.Fatalf("NewStaticKeyVerifier() error = %v", err)
+	}
+
+	const digest = "imagedigest"
+	payload := simpleSigningPayloadFor(digest)
+	signature := signPayload(t, key, payload)
+
+	t.Run("Valid signature verifies", func(t *testing.T) {
+		fetcher := buildFakeFetcher(t, "test/image1", digest, payload, signature)
+		if err := verifyTagSignature(context.Background(), fetcher, verifier, "test/image1", digest); err != nil {
+			t.Errorf("verifyTagSignature() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("Tampered signature is rejected", func(t *testing.T) {
+		tampered := append([]byte(nil), signature...)
+		tampered[0] ^= 0xFF
+		fetcher := buildFakeFetcher(t, "test/image1", digest, payload, tampered)
+		if err := verifyTagSignature(context.Background(), fetcher, verifier, "test/image1", digest); err == nil {
+			t.Error("verifyTagSignature() = nil, want error for tampered signature")
+		}
+	})
+
+	t.Run("Payload naming the wrong digest is rejected", func(t *testing.T) {
+		wrongPayload := simpleSigningPayloadFor("someotherdigest")
+		wrongSig := signPayload(t, key, wrongPayload)
+		fetcher := buildFakeFetcher(t, "test/image1", digest, wrongPayload, wrongSig)
+		if err := verifyTagSignature(context.Background(), fetcher, verifier, "test/image1", digest); err == nil {
+			t.Error("verifyTagSignature() = nil, want error for mismatched digest")
+		}
+	})
+
+	t.Run("Missing signature artifact is rejected", func(t *testing.T) {
+		fetcher := &fakeFetcher{manifests: map[string][]byte{}, blobs: map[string][]byte{}}
+		if err := verifyTagSignature(context.Background(), fetcher, verifier, "test/image1", digest); err == nil {
+			t.Error("verifyTagSignature() = nil, want error when no signature artifact exists")
+		}
+	})
+}
+
+func TestNoopVerifierAcceptsAnySignature(t *testing.T) {
+	const digest = "imagedigest"
+	payload := simpleSigningPayloadFor(digest)
+	fetcher := buildFakeFetcher(t, "test/image1", digest, payload, []byte("not-a-real-signature"))
+
+	if err := verifyTagSignature(context.Background(), fetcher, noopVerifier{}, "test/image1", digest); err != nil {
+		t.Errorf("verifyTagSignature() with noopVerifier error = %v, want nil", err)
+	}
+}
+
+func TestFilterVerified(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(keyPath, publicKeyPEM(t, key), 0644); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	verifier, err := NewStaticKeyVerifier(keyPath)
+	if err != nil {
+		t.Fatalf("NewStaticKeyVerifier() error = %v", err)
+	}
+
+	goodPayload := simpleSigningPayloadFor("good-digest")
+	goodSig := signPayload(t, key, goodPayload)
+	fetcher := buildFakeFetcher(t, "test/image1", "good-digest", goodPayload, goodSig)
+	// "bad-digest" has no signature artifact registered at all, so it fails lookup.
+
+	kept := map[string][]string{
+		"test/image1": {"good", "bad"},
+	}
+	digests := map[string]string{
+		"test/image1:good": "good-digest",
+		"test/image1:bad":  "bad-digest",
+	}
+
+	got := filterVerified(context.Background(), fetcher, verifier, kept, digests)
+	want := map[string][]string{
+		"test/image1": {"good"},
+	}
+	if got["test/image1"] == nil || len(got["test/image1"]) != 1 || got["test/image1"][0] != want["test/image1"][0] {
+		t.Errorf("filterVerified() = %v, want %v", got, want)
+	}
+}
+
+// makeKeylessCert builds a self-signed certificate shaped like a Fulcio
+// keyless-signing cert: a SAN URI identity and the Fulcio OIDC-issuer
+// extension, DER-encoded the way Fulcio actually encodes it (an ASN.1
+// UTF8String, not a raw string).
+func makeKeylessCert(t *testing.T, identityURI, issuer string) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	issuerValue, err := asn1.Marshal(issuer)
+	if err != nil {
+		t.Fatalf("failed to marshal issuer extension: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).AddDate(100, 0, 0),
+		URIs:         []*url.URL{{Scheme: "https", Host: "github.com", Path: "/" + identityURI}},
+		ExtraExtensions: []pkix.Extension{
+			{Id: fulcioIssuerOID, Value: issuerValue},
+		},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestKeylessVerifier(t *testing.T) {
+	const identity = "org/repo/.github/workflows/release.yml@refs/heads/main"
+	const issuer = "https://token.actions.githubusercontent.com"
+
+	key, certPEM := makeKeylessCert(t, identity, issuer)
+	payload := simpleSigningPayloadFor("imagedigest")
+	signature := signPayload(t, key, payload)
+	identityURI := "https://github.com/" + identity
+
+	t.Run("Matching identity and issuer verifies", func(t *testing.T) {
+		v := NewKeylessVerifier(identityURI, issuer)
+		if err := v.Verify(payload, signature, certPEM); err != nil {
+			t.Errorf("Verify() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("Mismatched identity is rejected", func(t *testing.T) {
+		v := NewKeylessVerifier("https://github.com/someone/else", issuer)
+		if err := v.Verify(payload, signature, certPEM); err == nil {
+			t.Error("Verify() = nil, want error for mismatched identity")
+		}
+	})
+
+	t.Run("Mismatched OIDC issuer is rejected", func(t *testing.T) {
+		v := NewKeylessVerifier(identityURI, "https://accounts.google.com")
+		if err := v.Verify(payload, signature, certPEM); err == nil {
+			t.Error("Verify() = nil, want error for mismatched OIDC issuer")
+		}
+	})
+
+	t.Run("Certificate's DER-encoded issuer is decoded, not compared raw", func(t *testing.T) {
+		// Regression test: the Fulcio issuer extension is itself an
+		// ASN.1-encoded UTF8String, so comparing its raw bytes against
+		// the plain issuer URL must never match even when they're
+		// semantically equal.
+		value, ok := certExtensionValue(parseCertPEM(t, certPEM), fulcioIssuerOID)
+		if !ok {
+			t.Fatal("certExtensionValue() ok = false, want true")
+		}
+		if value != issuer {
+			t.Errorf("certExtensionValue() = %q, want %q", value, issuer)
+		}
+	})
+
+	t.Run("No certificate is rejected", func(t *testing.T) {
+		v := NewKeylessVerifier(identityURI, issuer)
+		if err := v.Verify(payload, signature, nil); err == nil {
+			t.Error("Verify() = nil, want error when no certificate is attached")
+		}
+	})
+}
+
+func parseCertPEM(t *testing.T, certPEM []byte) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatal("failed to decode certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert
+}
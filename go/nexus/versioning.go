@@ -0,0 +1,439 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported --tag-scheme values.
+const (
+	tagSchemeSemver  = "semver"
+	tagSchemeNumeric = "numeric"
+	tagSchemeCalver  = "calver"
+	tagSchemeLexical = "lexical"
+	tagSchemeMaven   = "maven"
+	tagSchemePep440  = "pep440"
+)
+
+// semverVersion is a parsed MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD] tag.
+// Build metadata is discarded immediately since semver.org says it MUST be
+// ignored when determining precedence.
+type semverVersion struct {
+	major, minor, patch int
+	prerelease          []string // nil if the tag has no prerelease component
+}
+
+// parseSemverTag parses tag as a semantic version. Most tags in the wild
+// omit trailing zero components ("1", "1.25"), so unlike strict semver.org
+// parsing we accept 1, 2 or 3 dot-separated numeric components and treat the
+// missing ones as zero; anything else (non-numeric component, more than 3
+// parts, empty component) is rejected.
+func parseSemverTag(tag string) (semverVersion, bool) {
+	s := strings.TrimPrefix(tag, "v")
+
+	if idx := strings.IndexByte(s, '+'); idx >= 0 {
+		s = s[:idx] // build metadata: ignored for ordering
+	}
+
+	var prerelease []string
+	if idx := strings.IndexByte(s, '-'); idx >= 0 {
+		pre := s[idx+1:]
+		s = s[:idx]
+		if pre == "" {
+			return semverVersion{}, false
+		}
+		prerelease = strings.Split(pre, ".")
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return semverVersion{}, false
+	}
+
+	nums := [3]int{}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semverVersion{}, false
+		}
+		nums[i] = n
+	}
+
+	return semverVersion{major: nums[0], minor: nums[1], patch: nums[2], prerelease: prerelease}, true
+}
+
+// compareSemverTags returns >0 if a has higher precedence than b, <0 if
+// lower, 0 if equal, following semver.org's precedence rules.
+func compareSemverTags(a, b semverVersion) int {
+	if a.major != b.major {
+		return a.major - b.major
+	}
+	if a.minor != b.minor {
+		return a.minor - b.minor
+	}
+	if a.patch != b.patch {
+		return a.patch - b.patch
+	}
+	return comparePrerelease(a.prerelease, b.prerelease)
+}
+
+// comparePrerelease implements semver.org's rule 11: a version without a
+// prerelease has higher precedence than one with, and prereleases are
+// compared identifier by identifier (numeric identifiers compare
+// numerically and always sort below alphanumeric ones; alphanumeric
+// identifiers compare lexically).
+func comparePrerelease(a, b []string) int {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	if len(a) == 0 {
+		return 1
+	}
+	if len(b) == 0 {
+		return -1
+	}
+
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+func compareIdentifier(a, b string) int {
+	aNum, aIsNumeric := numericIdentifier(a)
+	bNum, bIsNumeric := numericIdentifier(b)
+
+	switch {
+	case aIsNumeric && bIsNumeric:
+		return aNum - bNum
+	case aIsNumeric && !bIsNumeric:
+		return -1
+	case !aIsNumeric && bIsNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func numericIdentifier(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseDottedInts splits tag on '.' and parses every component as a
+// non-negative integer. Used by the calver scheme, where tags look like
+// "2024.11.05" and should sort numerically component by component.
+func parseDottedInts(tag string) ([]int, bool) {
+	parts := strings.Split(tag, ".")
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+func compareDottedInts(a, b []int) int {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		var av, bv int
+		if i < len(a) {
+			av = a[i]
+		}
+		if i < len(b) {
+			bv = b[i]
+		}
+		if av != bv {
+			return av - bv
+		}
+	}
+	return 0
+}
+
+// mavenVersion is a parsed Maven-style version: a dotted sequence of numeric
+// components followed by an optional "-QUALIFIER" (e.g. "1.0-SNAPSHOT").
+// Maven's real ComparableVersion algorithm handles far more (qualifier
+// aliases like "ga"/"final", string components mixed into the numeric
+// sequence); this covers the common case the maven2 format actually needs:
+// numeric releases with an optional trailing qualifier.
+type mavenVersion struct {
+	numeric   []int
+	qualifier string // "" for an unqualified release
+}
+
+// parseMavenTag splits tag on its first '-' into a dotted-numeric release
+// and an optional qualifier, e.g. "1.0.1-SNAPSHOT" -> ([1 0 1], "SNAPSHOT").
+func parseMavenTag(tag string) (mavenVersion, bool) {
+	s := tag
+	qualifier := ""
+	if idx := strings.IndexByte(s, '-'); idx >= 0 {
+		qualifier = s[idx+1:]
+		s = s[:idx]
+	}
+	nums, ok := parseDottedInts(s)
+	if !ok {
+		return mavenVersion{}, false
+	}
+	return mavenVersion{numeric: nums, qualifier: qualifier}, true
+}
+
+// compareMavenTags returns >0 if a has higher precedence than b: the
+// dotted-numeric release compares first, then an unqualified release
+// outranks any qualified one (including "SNAPSHOT"), and two qualified
+// releases fall back to lexical order.
+func compareMavenTags(a, b mavenVersion) int {
+	if c := compareDottedInts(a.numeric, b.numeric); c != 0 {
+		return c
+	}
+	switch {
+	case a.qualifier == "" && b.qualifier == "":
+		return 0
+	case a.qualifier == "":
+		return 1
+	case b.qualifier == "":
+		return -1
+	default:
+		return strings.Compare(a.qualifier, b.qualifier)
+	}
+}
+
+// pep440Pattern matches the PEP 440 release segment plus its optional
+// pre-release (a/b/rcN), post-release (.postN) and dev-release (.devN)
+// suffixes. Epochs ("1!2.0") and local versions ("1.0+local") aren't
+// supported; pypi packages using them fail to parse under pep440 like any
+// other unrecognized tag.
+var pep440Pattern = regexp.MustCompile(`^(\d+(?:\.\d+)*)(?:(a|b|rc)(\d+))?(?:\.post(\d+))?(?:\.dev(\d+))?$`)
+
+// pep440Version is a parsed subset of PEP 440 (see pep440Pattern).
+type pep440Version struct {
+	release []int
+	pre     string // "a", "b", "rc", or "" if this isn't a pre-release
+	preNum  int
+	post    int // -1 if this isn't a post-release
+	dev     int // -1 if this isn't a dev-release
+}
+
+func parsePep440Tag(tag string) (pep440Version, bool) {
+	m := pep440Pattern.FindStringSubmatch(tag)
+	if m == nil {
+		return pep440Version{}, false
+	}
+	release, ok := parseDottedInts(m[1])
+	if !ok {
+		return pep440Version{}, false
+	}
+
+	v := pep440Version{release: release, post: -1, dev: -1}
+	if m[2] != "" {
+		v.pre = m[2]
+		v.preNum, _ = strconv.Atoi(m[3])
+	}
+	if m[4] != "" {
+		v.post, _ = strconv.Atoi(m[4])
+	}
+	if m[5] != "" {
+		v.dev, _ = strconv.Atoi(m[5])
+	}
+	return v, true
+}
+
+// comparePep440Tags returns >0 if a has higher precedence than b, following
+// PEP 440's ordering of suffixes: dev releases sort lowest, then
+// pre-releases (alpha < beta < rc), then the final release, then
+// post-releases highest.
+func comparePep440Tags(a, b pep440Version) int {
+	if c := compareDottedInts(a.release, b.release); c != 0 {
+		return c
+	}
+	aRank, bRank := pep440PhaseRank(a), pep440PhaseRank(b)
+	if c := aRank - bRank; c != 0 {
+		return c
+	}
+	switch aRank {
+	case pep440PhasePre:
+		if c := pep440PreRank(a.pre) - pep440PreRank(b.pre); c != 0 {
+			return c
+		}
+		if c := a.preNum - b.preNum; c != 0 {
+			return c
+		}
+		if c := a.post - b.post; c != 0 {
+			return c
+		}
+		return comparePep440Dev(a, b)
+	case pep440PhaseFinal:
+		if c := a.post - b.post; c != 0 {
+			return c
+		}
+		return comparePep440Dev(a, b)
+	default: // pep440PhaseDev
+		return a.dev - b.dev
+	}
+}
+
+// comparePep440Dev breaks ties within a phase by dev-release number. A
+// version with no dev suffix (dev < 0) outranks one that has one, since
+// e.g. "1.0" is the finalized release of "1.0.dev1".
+func comparePep440Dev(a, b pep440Version) int {
+	switch {
+	case a.dev < 0 && b.dev < 0:
+		return 0
+	case a.dev < 0:
+		return 1
+	case b.dev < 0:
+		return -1
+	default:
+		return a.dev - b.dev
+	}
+}
+
+// pep440 phase ranks, lowest precedence first. A dev release of a post
+// release (e.g. "1.0.post1.dev1") is NOT a plain dev build: per PEP 440 it
+// ranks between the base release and its finalized post release, so it
+// belongs in pep440PhaseFinal alongside "1.0" and "1.0.post1", not in
+// pep440PhaseDev with "1.0.dev1".
+const (
+	pep440PhaseDev = iota
+	pep440PhasePre
+	pep440PhaseFinal
+)
+
+// pep440PhaseRank buckets a version into dev < pre < final so versions in
+// different phases compare correctly regardless of their within-phase
+// numbers. dev and post suffixes are then broken out by comparePep440Tags.
+func pep440PhaseRank(v pep440Version) int {
+	switch {
+	case v.pre != "":
+		return pep440PhasePre
+	case v.post < 0 && v.dev >= 0:
+		return pep440PhaseDev
+	default:
+		return pep440PhaseFinal
+	}
+}
+
+func pep440PreRank(pre string) int {
+	switch pre {
+	case "a":
+		return 0
+	case "b":
+		return 1
+	case "rc":
+		return 2
+	default:
+		return -1
+	}
+}
+
+// tagKey is a parsed tag under whichever --tag-scheme is active, ready to be
+// compared against another tagKey parsed under the same scheme.
+type tagKey struct {
+	scheme  string
+	semver  semverVersion
+	numeric int
+	calver  []int
+	lexical string
+	maven   mavenVersion
+	pep440  pep440Version
+}
+
+// parseTagKey parses tag under scheme, reporting false if tag doesn't
+// conform to that scheme (e.g. "latest" under any scheme, or "1.0-rc1"
+// under numeric).
+func parseTagKey(tag, scheme string) (tagKey, bool) {
+	switch scheme {
+	case tagSchemeSemver:
+		v, ok := parseSemverTag(tag)
+		return tagKey{scheme: scheme, semver: v}, ok
+	case tagSchemeNumeric:
+		n, err := strconv.Atoi(tag)
+		return tagKey{scheme: scheme, numeric: n}, err == nil
+	case tagSchemeCalver:
+		nums, ok := parseDottedInts(tag)
+		return tagKey{scheme: scheme, calver: nums}, ok
+	case tagSchemeLexical:
+		return tagKey{scheme: scheme, lexical: tag}, true
+	case tagSchemeMaven:
+		v, ok := parseMavenTag(tag)
+		return tagKey{scheme: scheme, maven: v}, ok
+	case tagSchemePep440:
+		v, ok := parsePep440Tag(tag)
+		return tagKey{scheme: scheme, pep440: v}, ok
+	default:
+		return tagKey{}, false
+	}
+}
+
+func compareTagKeys(a, b tagKey) int {
+	switch a.scheme {
+	case tagSchemeSemver:
+		return compareSemverTags(a.semver, b.semver)
+	case tagSchemeNumeric:
+		return a.numeric - b.numeric
+	case tagSchemeCalver:
+		return compareDottedInts(a.calver, b.calver)
+	case tagSchemeLexical:
+		return strings.Compare(a.lexical, b.lexical)
+	case tagSchemeMaven:
+		return compareMavenTags(a.maven, b.maven)
+	case tagSchemePep440:
+		return comparePep440Tags(a.pep440, b.pep440)
+	default:
+		return 0
+	}
+}
+
+// rankTags parses and sorts tags (highest precedence first) under scheme,
+// returning separately the tags that don't parse under that scheme at all.
+func rankTags(tags []string, scheme string) (ranked []string, unparsed []string) {
+	type entry struct {
+		tag string
+		key tagKey
+	}
+
+	var entries []entry
+	for _, tag := range tags {
+		key, ok := parseTagKey(tag, scheme)
+		if !ok {
+			unparsed = append(unparsed, tag)
+			continue
+		}
+		entries = append(entries, entry{tag: tag, key: key})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return compareTagKeys(entries[i].key, entries[j].key) > 0
+	})
+
+	ranked = make([]string, len(entries))
+	for i, e := range entries {
+		ranked[i] = e.tag
+	}
+
+	return ranked, unparsed
+}